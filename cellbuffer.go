@@ -0,0 +1,288 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/rivo/uniseg"
+)
+
+// cell is a single terminal cell. It holds one grapheme cluster along with the
+// styling that was in effect when the cluster was written.
+type cell struct {
+	// content is the grapheme cluster painted in this cell. It's empty for the
+	// trailing cells of a wide grapheme cluster.
+	content string
+
+	// style contains the escape sequences that need to be written before the
+	// content to reproduce its styling. An empty style means no styling.
+	style string
+
+	// width is the number of columns occupied by the content. Trailing cells
+	// of a wide grapheme cluster have a width of 0.
+	width int
+}
+
+// cellLine is a single line of cells, indexed by column.
+type cellLine []cell
+
+// sgrReset resets all text attributes.
+const sgrReset = termenv.CSI + termenv.ResetSeq + "m"
+
+// parseCellLines parses a frame into lines of cells. If width is greater than
+// 0, lines are truncated to width columns.
+func parseCellLines(lines []string, width int) []cellLine {
+	cells := make([]cellLine, len(lines))
+	for i, l := range lines {
+		cells[i] = parseCellLine(l, width)
+	}
+	return cells
+}
+
+// tabWidth is the distance between tab stops, as set by default by terminals.
+const tabWidth = 8
+
+// parseCellLine parses a single line of, possibly styled, output into cells.
+// SGR sequences accumulate into the style of the cells that follow them until
+// they're reset. Other escape sequences are carried along with the style as
+// well, so they must not move the cursor.
+//
+// If width is greater than 0 the line is truncated to width columns. A wide
+// grapheme cluster that would straddle the edge is dropped. Tabs are expanded
+// to blank cells up to the next tab stop.
+func parseCellLine(s string, width int) cellLine {
+	var (
+		line  cellLine
+		style string
+		state = -1
+	)
+
+	for len(s) > 0 {
+		if s[0] == '\x1b' {
+			n := escapeSequenceLen(s)
+			seq := s[:n]
+			s = s[n:]
+			state = -1 // escape sequences break grapheme clusters
+			if isSGRReset(seq) {
+				style = ""
+			} else {
+				style += seq
+			}
+			continue
+		}
+
+		if s[0] == '\t' {
+			// A tab moves the cursor to the next tab stop without painting
+			// the cells it skips, which are blank.
+			s = s[1:]
+			state = -1
+			n := tabWidth - len(line)%tabWidth
+			if width > 0 && len(line)+n > width {
+				n = width - len(line)
+			}
+			for i := 0; i < n; i++ {
+				line = append(line, cell{content: " ", width: 1})
+			}
+			continue
+		}
+
+		var (
+			cluster string
+			w       int
+		)
+		cluster, s, w, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if w == 0 {
+			// Zero-width clusters, such as stray control characters, can't
+			// occupy a cell of their own.
+			continue
+		}
+		if width > 0 && len(line)+w > width {
+			break
+		}
+
+		line = append(line, cell{content: cluster, style: style, width: w})
+		for i := 1; i < w; i++ {
+			line = append(line, cell{style: style})
+		}
+	}
+
+	return line
+}
+
+// escapeSequenceLen returns the length of the escape sequence at the start of
+// s, which must begin with an escape character. Unterminated sequences span
+// the remainder of s.
+func escapeSequenceLen(s string) int {
+	if len(s) < 2 { //nolint:gomnd
+		return len(s)
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes followed by a final byte.
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']', 'P', '_', '^':
+		// OSC, DCS, APC and PM: terminated by BEL or ST.
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2 //nolint:gomnd
+			}
+		}
+	default:
+		return 2 //nolint:gomnd
+	}
+
+	return len(s)
+}
+
+// isSGRReset reports whether the given escape sequence resets all text
+// attributes without setting new ones.
+func isSGRReset(seq string) bool {
+	return seq == sgrReset || seq == termenv.CSI+"m"
+}
+
+// cellCursor tracks the position of the cursor while painting a cell diff.
+// Positions are relative to the top of the area managed by the renderer.
+type cellCursor struct {
+	out   *termenv.Output
+	width int
+
+	x, y int
+
+	// lines is the number of lines that exist on the screen. Moving below
+	// the last one requires writing newlines.
+	lines int
+}
+
+// moveTo moves the cursor to the given position.
+func (c *cellCursor) moveTo(y, x int) {
+	switch {
+	case y < c.y:
+		c.out.CursorUp(c.y - y)
+	case y > c.y:
+		if y < c.lines {
+			c.out.CursorDown(y - c.y)
+			break
+		}
+		if c.lines-1 > c.y {
+			c.out.CursorDown(c.lines - 1 - c.y)
+		}
+		// Write newlines to make room for the new lines, scrolling the
+		// terminal if needed.
+		_, _ = c.out.WriteString(strings.Repeat("\r\n", y-c.lines+1))
+		c.lines = y + 1
+		c.x = 0
+	}
+	c.y = y
+
+	switch {
+	case x == c.x:
+	case x == 0:
+		_, _ = c.out.WriteString("\r")
+	case x > c.x && c.x >= 0:
+		c.out.CursorForward(x - c.x)
+	default:
+		_, _ = c.out.WriteString("\r")
+		c.out.CursorForward(x)
+	}
+	c.x = x
+}
+
+// advance records that n columns were written at the cursor position.
+func (c *cellCursor) advance(n int) {
+	c.x += n
+	if c.width > 0 && c.x >= c.width {
+		// The cursor is stuck in the last column waiting to wrap, so we
+		// don't know where the next write will land.
+		c.x = -1
+	}
+}
+
+// renderCellDiff writes the sequences needed to turn the screen described by
+// prev into the one described by next. Only runs of cells that changed are
+// written. The cursor is expected to be at the start of the last line of prev
+// and is left at the start of the last line of next.
+func renderCellDiff(out *termenv.Output, prev, next []cellLine, width int) {
+	c := &cellCursor{
+		out:   out,
+		width: width,
+		lines: len(prev),
+	}
+	if c.lines == 0 {
+		// Nothing was rendered, but the cursor still sits on a line.
+		c.lines = 1
+	}
+	c.y = c.lines - 1
+
+	for y, nl := range next {
+		var pl cellLine
+		if y < len(prev) {
+			pl = prev[y]
+		}
+
+		for x := 0; x < len(nl); {
+			if x < len(pl) && nl[x] == pl[x] {
+				x++
+				continue
+			}
+
+			// Find the run of changed cells, making sure we start and end on
+			// grapheme boundaries.
+			start := x
+			for start > 0 && nl[start].width == 0 {
+				start--
+			}
+			end := x
+			for end < len(nl) && (end >= len(pl) || nl[end] != pl[end] || nl[end].width == 0) {
+				end++
+			}
+
+			c.moveTo(y, start)
+			writeCells(out, nl[start:end])
+			c.advance(end - start)
+			x = end
+		}
+
+		// Clear whatever is left over from the previous line.
+		if len(pl) > len(nl) {
+			c.moveTo(y, len(nl))
+			out.ClearLineRight()
+		}
+	}
+
+	// Clear lines that are no longer rendered.
+	for y := len(next); y < len(prev); y++ {
+		c.moveTo(y, 0)
+		out.ClearLine()
+	}
+
+	c.moveTo(len(next)-1, 0)
+}
+
+// writeCells writes a run of cells, resetting the style at the end of the run.
+func writeCells(out *termenv.Output, cells cellLine) {
+	var style string
+	for _, c := range cells {
+		if c.width == 0 {
+			continue
+		}
+		if c.style != style {
+			if style != "" {
+				_, _ = out.WriteString(sgrReset)
+			}
+			_, _ = out.WriteString(c.style)
+			style = c.style
+		}
+		_, _ = out.WriteString(c.content)
+	}
+	if style != "" {
+		_, _ = out.WriteString(sgrReset)
+	}
+}
@@ -0,0 +1,179 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestParseCellLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		width    int
+		expected cellLine
+	}{
+		{
+			name:     "empty",
+			line:     "",
+			expected: nil,
+		},
+		{
+			name: "plain",
+			line: "ab",
+			expected: cellLine{
+				{content: "a", width: 1},
+				{content: "b", width: 1},
+			},
+		},
+		{
+			name: "styled",
+			line: "a\x1b[1m\x1b[31mb\x1b[0mc",
+			expected: cellLine{
+				{content: "a", width: 1},
+				{content: "b", style: "\x1b[1m\x1b[31m", width: 1},
+				{content: "c", width: 1},
+			},
+		},
+		{
+			name: "wide",
+			line: "\x1b[1m世\x1b[mx",
+			expected: cellLine{
+				{content: "世", style: "\x1b[1m", width: 2},
+				{style: "\x1b[1m"},
+				{content: "x", width: 1},
+			},
+		},
+		{
+			name: "grapheme cluster",
+			line: "é!",
+			expected: cellLine{
+				{content: "é", width: 1},
+				{content: "!", width: 1},
+			},
+		},
+		{
+			name: "tab",
+			line: "ab\tc",
+			expected: cellLine{
+				{content: "a", width: 1},
+				{content: "b", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: "c", width: 1},
+			},
+		},
+		{
+			name:  "truncated tab",
+			line:  "a\tb",
+			width: 4,
+			expected: cellLine{
+				{content: "a", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+				{content: " ", width: 1},
+			},
+		},
+		{
+			name:  "truncated",
+			line:  "abc",
+			width: 2,
+			expected: cellLine{
+				{content: "a", width: 1},
+				{content: "b", width: 1},
+			},
+		},
+		{
+			name:  "truncated wide",
+			line:  "a世",
+			width: 2,
+			expected: cellLine{
+				{content: "a", width: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseCellLine(test.line, test.width)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected cells:\n%#v\ngot:\n%#v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderCellDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     []string
+		next     []string
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			prev:     []string{"hello", "world"},
+			next:     []string{"hello", "world"},
+			expected: "",
+		},
+		{
+			name:     "single cell",
+			prev:     []string{"count: 1", "status: ok"},
+			next:     []string{"count: 2", "status: ok"},
+			expected: "\x1b[1A\x1b[7C2\x1b[1B\r",
+		},
+		{
+			name:     "styled cell",
+			prev:     []string{"a\x1b[31mb\x1b[0m"},
+			next:     []string{"a\x1b[32mb\x1b[0m"},
+			expected: "\x1b[1C\x1b[32mb\x1b[0m\r",
+		},
+		{
+			name:     "shorter line",
+			prev:     []string{"abcd"},
+			next:     []string{"ab"},
+			expected: "\x1b[2C\x1b[0K\r",
+		},
+		{
+			name:     "wide cell",
+			prev:     []string{"a世b"},
+			next:     []string{"a界b"},
+			expected: "\x1b[1C界\r",
+		},
+		{
+			name:     "after a tab",
+			prev:     []string{"a\tb"},
+			next:     []string{"a\tc"},
+			expected: "\x1b[8Cc\r",
+		},
+		{
+			name:     "more lines",
+			prev:     []string{"a"},
+			next:     []string{"a", "b"},
+			expected: "\r\nb\r",
+		},
+		{
+			name:     "fewer lines",
+			prev:     []string{"a", "b"},
+			next:     []string{"a"},
+			expected: "\x1b[2K\x1b[1A",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := termenv.NewOutput(&buf)
+			renderCellDiff(out, parseCellLines(test.prev, 0), parseCellLines(test.next, 0), 0)
+			if buf.String() != test.expected {
+				t.Errorf("expected output:\n%q\ngot:\n%q", test.expected, buf.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package tea
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +16,10 @@ import (
 //	    func (m model) Init() Cmd {
 //		       return tea.Batch(someCommand, someOtherCommand)
 //	    }
+//
+// Batches and sequences can be nested within each other at any depth. Within
+// a Sequence, a batch is complete once all of its commands, including the
+// commands of the batches and sequences they return, have returned.
 func Batch(cmds ...Cmd) Cmd {
 	var validCmds []Cmd //nolint:prealloc
 	for _, c := range cmds {
@@ -36,8 +44,68 @@ func Batch(cmds ...Cmd) Cmd {
 // no ordering guarantees. You can send a BatchMsg with Batch.
 type BatchMsg []Cmd
 
+// All produces a command that runs the given commands concurrently and sends
+// a single AllMsg with all of their messages, once they've all returned. Use
+// All rather than Batch when the messages are only useful together:
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.All(loadUser, loadSettings)
+//	}
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case tea.AllMsg:
+//	        m.user, m.settings = msg[0].(userMsg), msg[1].(settingsMsg)
+//	    }
+//	    // ...
+//	}
+//
+// The commands' messages are only delivered in the AllMsg. Commands that
+// return a Batch or a Sequence are waited for too, and their messages are
+// collected in a nested AllMsg. The message of a command created with Latest
+// is nil if a newer command was issued with the same key. Commands created
+// with Debounce or Throttle aren't waited for: they're debounced or throttled
+// as usual, their messages are sent to Update on their own, and their
+// messages in the AllMsg are nil.
+//
+// With WithMaxConcurrentCommands, each of the commands takes a slot for
+// concurrent commands while it runs, like any other command.
+func All(cmds ...Cmd) Cmd {
+	return func() Msg {
+		return allMsg{cmds: cmds}
+	}
+}
+
+// AllOrError is like All, but if a command returns a message that's an error,
+// the other commands are cancelled, if they were created with CmdContext, and
+// that message is sent instead of an AllMsg.
+func AllOrError(cmds ...Cmd) Cmd {
+	return func() Msg {
+		return allMsg{cmds: cmds, orError: true}
+	}
+}
+
+// AllMsg is sent when the commands run with All have all returned. It holds
+// their messages in the order of the commands, with nil for nil commands.
+type AllMsg []Msg
+
+// allMsg is used internally to run commands with All.
+type allMsg struct {
+	cmds    []Cmd
+	orError bool
+}
+
 // Sequence runs the given commands one at a time, in order. Contrast this with
 // Batch, which runs commands concurrently.
+//
+// A command that returns a Batch or another Sequence is complete once all of
+// the commands they contain have returned, at any depth, and the messages of
+// these commands are delivered before the next command runs:
+//
+//	// Sends a, then b and c in any order, then d and e in order, then f.
+//	tea.Sequence(a, tea.Batch(b, c), tea.Sequence(d, e), f)
+//
+// Commands that are still waiting to run when the program exits are skipped.
 func Sequence(cmds ...Cmd) Cmd {
 	return func() Msg {
 		return sequenceMsg(cmds)
@@ -147,6 +215,302 @@ func Tick(d time.Duration, fn func(time.Time) Msg) Cmd {
 	}
 }
 
+// CmdContext produces a command from a function that takes a context. The
+// context is cancelled when the program quits or is killed, so long-running
+// work such as HTTP requests and timers can stop early instead of outliving
+// the program:
+//
+//	func fetch(url string) tea.Cmd {
+//	    return tea.CmdContext(func(ctx context.Context) tea.Msg {
+//	        req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	        if err != nil {
+//	            return errMsg{err}
+//	        }
+//	        res, err := http.DefaultClient.Do(req)
+//	        if err != nil {
+//	            return errMsg{err}
+//	        }
+//	        defer res.Body.Close()
+//	        return statusMsg(res.StatusCode)
+//	    })
+//	}
+//
+// Messages returned after the program has exited are discarded. To give
+// commands a chance to finish when the program exits, use
+// WithShutdownTimeout.
+func CmdContext(fn func(ctx context.Context) Msg) Cmd {
+	return func() Msg {
+		return contextCmdMsg(fn)
+	}
+}
+
+// contextCmdMsg is used internally to run a command that takes the program's
+// context.
+type contextCmdMsg func(ctx context.Context) Msg
+
+// WithPriority sets the priority of a command run by a program with a limit on
+// concurrent commands, set with WithMaxConcurrentCommands. Commands waiting to
+// run are run by order of priority, highest first, and in the order they were
+// issued within the same priority. Commands have a priority of 0 by default,
+// and the commands of a Batch inherit the priority of the batch.
+//
+// For example, giving background work a negative priority lets the commands
+// triggered by the user run first:
+//
+//	return m, tea.WithPriority(tea.Batch(statCmds...), -1)
+//
+// The priority has no effect on programs without a limit on concurrent
+// commands, where every command runs right away.
+func WithPriority(cmd Cmd, priority int) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return priorityCmdMsg{cmd: cmd, priority: priority}.msg
+}
+
+// priorityCmdMsg is used internally to run a command with a priority.
+type priorityCmdMsg struct {
+	cmd      Cmd
+	priority int
+}
+
+// msg is the command created by WithPriority. Being a method, it has the same
+// code for all priorities, which lets the command pool recognize it.
+func (m priorityCmdMsg) msg() Msg {
+	return m
+}
+
+// Debounce produces a command that runs cmd once no other command was
+// debounced with the same ID for the given duration. Each call with the same
+// ID restarts the wait and replaces the command to run, so only the last one
+// of a burst runs. This is useful for running expensive work, such as a
+// search, once the user stops typing:
+//
+//	case tea.KeyMsg:
+//	    m.input, cmd = m.input.Update(msg)
+//	    return m, tea.Batch(cmd, tea.Debounce("search", 300*time.Millisecond, search(m.input.Value())))
+//
+// The program keeps track of the timers, so the model doesn't have to.
+func Debounce(id string, d time.Duration, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return debounceMsg{id: id, d: d, cmd: cmd}
+	}
+}
+
+// debounceMsg is used internally to debounce a command.
+type debounceMsg struct {
+	id  string
+	d   time.Duration
+	cmd Cmd
+}
+
+// Throttle produces a command that runs cmd at most once per the given
+// duration for each ID. The first command throttled with an ID runs right
+// away. Commands throttled with the same ID during the following duration
+// don't run, except for the last of them, which runs at the end of the
+// duration and starts a new one. This is useful for limiting the rate of
+// work triggered by frequent events, such as mouse motion:
+//
+//	case tea.MouseMsg:
+//	    return m, tea.Throttle("preview", 100*time.Millisecond, preview(msg.X, msg.Y))
+func Throttle(id string, d time.Duration, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return throttleMsg{id: id, d: d, cmd: cmd}
+	}
+}
+
+// throttleMsg is used internally to throttle a command.
+type throttleMsg struct {
+	id  string
+	d   time.Duration
+	cmd Cmd
+}
+
+// WithTimeout produces a command that runs cmd, and returns its message if it
+// returns within the given duration, or onTimeout otherwise. If cmd was
+// created with CmdContext, its context is cancelled when it times out.
+//
+//	cmd := tea.WithTimeout(fetch(url), 5*time.Second, errMsg{errors.New("request timed out")})
+func WithTimeout(cmd Cmd, d time.Duration, onTimeout Msg) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return timeoutCmdMsg{cmd: cmd, d: d, onTimeout: onTimeout}
+	}
+}
+
+// timeoutCmdMsg is used internally to run a command with a timeout.
+type timeoutCmdMsg struct {
+	cmd       Cmd
+	d         time.Duration
+	onTimeout Msg
+}
+
+// Latest produces a command whose message is only delivered if no newer
+// command was issued with the same key in the meantime. Issuing a command with
+// a key cancels the command in flight with the same key, if it was created
+// with CmdContext, and discards its message either way. This is useful when
+// only the result of the latest request matters, such as for search as you
+// type:
+//
+//	case tea.KeyMsg:
+//	    m.input, _ = m.input.Update(msg)
+//	    return m, tea.Latest("search", search(m.input.Value()))
+//
+// Commands are ordered by when Latest is called, so a slow command issued
+// first can't replace the message of a faster command issued after it.
+func Latest(key string, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	seq := atomic.AddUint64(&latestSeq, 1)
+	return func() Msg {
+		return latestCmdMsg{key: key, seq: seq, cmd: cmd}
+	}
+}
+
+// latestSeq orders the commands created with Latest.
+var latestSeq uint64
+
+// latestCmdMsg is used internally to run a command created with Latest.
+type latestCmdMsg struct {
+	key string
+	seq uint64
+	cmd Cmd
+}
+
+// Default retry settings.
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryDelay      = 100 * time.Millisecond
+	defaultRetryMultiplier = 2
+)
+
+// RetryPolicy configures how Retry retries a command. Zero values are
+// replaced with defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the command runs,
+	// including the first one. It defaults to 3.
+	MaxAttempts int
+
+	// Delay is the time to wait before the first retry. It defaults to
+	// 100ms.
+	Delay time.Duration
+
+	// Multiplier is the factor by which the delay grows after each retry.
+	// It defaults to 2.
+	Multiplier float64
+
+	// MaxDelay caps the delay between retries. There's no cap by default.
+	MaxDelay time.Duration
+
+	// Jitter randomly shortens each delay by up to this fraction of it, from
+	// 0 to 1, so that clients that fail together don't all retry at the same
+	// time. There's no jitter by default.
+	Jitter float64
+
+	// Failed reports whether a message of the command is a failure that
+	// should be retried. By default, messages that are errors are failures.
+	Failed func(Msg) bool
+
+	// ReportAttempts makes Retry send a RetryMsg to Update for every failed
+	// attempt that will be retried.
+	ReportAttempts bool
+}
+
+// delay returns the time to wait after the given failed attempt, where rnd is
+// a random number in [0, 1) for the jitter.
+func (r RetryPolicy) delay(attempt int, rnd float64) time.Duration {
+	d := float64(r.Delay) * math.Pow(r.Multiplier, float64(attempt-1))
+	if r.MaxDelay > 0 && d > float64(r.MaxDelay) {
+		d = float64(r.MaxDelay)
+	}
+	d -= d * r.Jitter * rnd
+	return time.Duration(d)
+}
+
+// withDefaults returns the policy with its zero values replaced with
+// defaults.
+func (r RetryPolicy) withDefaults() RetryPolicy {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = defaultRetryAttempts
+	}
+	if r.Delay <= 0 {
+		r.Delay = defaultRetryDelay
+	}
+	if r.Multiplier <= 0 {
+		r.Multiplier = defaultRetryMultiplier
+	}
+	if r.Jitter < 0 {
+		r.Jitter = 0
+	} else if r.Jitter > 1 {
+		r.Jitter = 1
+	}
+	if r.Failed == nil {
+		r.Failed = func(msg Msg) bool {
+			_, ok := msg.(error)
+			return ok
+		}
+	}
+	return r
+}
+
+// RetryMsg is sent when an attempt of a command run with Retry failed and the
+// command will be retried, if the policy has ReportAttempts set.
+type RetryMsg struct {
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+
+	// MaxAttempts is the maximum number of attempts.
+	MaxAttempts int
+
+	// Msg is the message of the failed attempt.
+	Msg Msg
+
+	// Delay is the time until the next attempt.
+	Delay time.Duration
+}
+
+// Retry produces a command that runs cmd again, with exponential backoff,
+// when its message is a failure according to the given policy. The message of
+// the last attempt is sent, whether it failed or not:
+//
+//	cmd := tea.Retry(fetch(url), tea.RetryPolicy{
+//	    MaxAttempts:    5,
+//	    Delay:          time.Second,
+//	    Jitter:         0.5,
+//	    ReportAttempts: true,
+//	})
+//
+//	// In Update:
+//	case tea.RetryMsg:
+//	    m.status = fmt.Sprintf("retrying (%d/%d)", msg.Attempt+1, msg.MaxAttempts)
+//
+// If cmd was created with CmdContext, every attempt gets the program's
+// context. Retrying stops when the program exits.
+func Retry(cmd Cmd, policy RetryPolicy) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	policy = policy.withDefaults()
+	return func() Msg {
+		return retryMsg{cmd: cmd, policy: policy}
+	}
+}
+
+// retryMsg is used internally to run a command with Retry.
+type retryMsg struct {
+	cmd    Cmd
+	policy RetryPolicy
+}
+
 // Sequentially produces a command that sequentially executes the given
 // commands.
 // The Msg returned is the first non-nil message returned by a Cmd.
@@ -191,3 +555,244 @@ func SetWindowTitle(title string) Cmd {
 		return setWindowTitleMsg(title)
 	}
 }
+
+// timedCmd is a command waiting for a debounce or throttle timer.
+type timedCmd struct {
+	timer *time.Timer
+	d     time.Duration
+	gen   uint64 // tells the current timer of a debounce from stopped ones
+	cmd   Cmd
+}
+
+// timerFiredMsg is used internally when the timer of a debounce or throttle
+// fires.
+type timerFiredMsg struct {
+	throttle bool
+	id       string
+	gen      uint64
+}
+
+// debounce (re)starts the timer of a debounced command.
+func (p *Program) debounce(msg debounceMsg) {
+	if p.debounced == nil {
+		p.debounced = map[string]*timedCmd{}
+	}
+	t, ok := p.debounced[msg.id]
+	if !ok {
+		t = &timedCmd{}
+		p.debounced[msg.id] = t
+	} else {
+		t.timer.Stop()
+	}
+
+	t.gen++
+	t.cmd = msg.cmd
+	fired := timerFiredMsg{id: msg.id, gen: t.gen}
+	t.timer = time.AfterFunc(msg.d, func() { p.Send(fired) })
+}
+
+// throttle runs a throttled command, or keeps it for the end of the current
+// interval.
+func (p *Program) throttle(msg throttleMsg, cmds chan Cmd) {
+	if p.throttled == nil {
+		p.throttled = map[string]*timedCmd{}
+	}
+	if t, ok := p.throttled[msg.id]; ok {
+		t.cmd = msg.cmd
+		return
+	}
+
+	select {
+	case <-p.ctx.Done():
+		return
+	case cmds <- msg.cmd:
+	}
+	fired := timerFiredMsg{throttle: true, id: msg.id}
+	p.throttled[msg.id] = &timedCmd{
+		timer: time.AfterFunc(msg.d, func() { p.Send(fired) }),
+		d:     msg.d,
+	}
+}
+
+// timerFired runs the command of a debounce or throttle whose timer fired.
+func (p *Program) timerFired(msg timerFiredMsg, cmds chan Cmd) {
+	if !msg.throttle {
+		t, ok := p.debounced[msg.id]
+		if !ok || t.gen != msg.gen {
+			return
+		}
+		delete(p.debounced, msg.id)
+		select {
+		case <-p.ctx.Done():
+		case cmds <- t.cmd:
+		}
+		return
+	}
+
+	t, ok := p.throttled[msg.id]
+	if !ok {
+		return
+	}
+	delete(p.throttled, msg.id)
+	if t.cmd != nil {
+		// Run the last command of the interval, which starts a new one.
+		p.throttle(throttleMsg{id: msg.id, d: t.d, cmd: t.cmd}, cmds)
+	}
+}
+
+// latestCmd is the newest command issued with a key with Latest.
+type latestCmd struct {
+	seq    uint64
+	cancel context.CancelFunc
+}
+
+// latestResultMsg is used internally to deliver the message of a command
+// created with Latest, unless a newer command was issued with the same key.
+type latestResultMsg struct {
+	key string
+	seq uint64
+	msg Msg
+}
+
+// runLatest runs a command created with Latest, unless a newer command was
+// issued with the same key, after cancelling the older one.
+func (p *Program) runLatest(ctx context.Context, msg latestCmdMsg) Msg {
+	p.latestMtx.Lock()
+	if p.latest == nil {
+		p.latest = map[string]*latestCmd{}
+	}
+	if cur, ok := p.latest[msg.key]; ok {
+		if cur.seq > msg.seq {
+			p.latestMtx.Unlock()
+			return nil
+		}
+		cur.cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.latest[msg.key] = &latestCmd{seq: msg.seq, cancel: cancel}
+	p.latestMtx.Unlock()
+
+	return latestResultMsg{key: msg.key, seq: msg.seq, msg: p.runCmd(ctx, msg.cmd)}
+}
+
+// isLatest reports whether no newer command was issued with the key of a
+// Latest command's message.
+func (p *Program) isLatest(msg latestResultMsg) bool {
+	p.latestMtx.Lock()
+	defer p.latestMtx.Unlock()
+	return p.latest[msg.key].seq == msg.seq
+}
+
+// runAll runs commands created with All or AllOrError. The commands take
+// slots for concurrent commands of their own, so the command that returned
+// the All gives its slot up while it waits for them.
+func (p *Program) runAll(ctx context.Context, msg allMsg) Msg {
+	releaseCmdSlot(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i   int
+		msg Msg
+	}
+	results := make(chan result, len(msg.cmds))
+	n := 0
+	for i, cmd := range msg.cmds {
+		if cmd == nil {
+			continue
+		}
+		n++
+		i, cmd := i, cmd
+		p.runningCmds.Add(1)
+		go func() {
+			defer p.runningCmds.Done()
+			var m Msg
+			defer func() { results <- result{i: i, msg: m} }()
+			defer p.recoverPanic()
+			m = p.collect(ctx, p.runLimited(ctx, cmd), msg.orError)
+		}()
+	}
+
+	msgs := make(AllMsg, len(msg.cmds))
+	for ; n > 0; n-- {
+		r := <-results
+		if _, ok := r.msg.(error); ok && msg.orError {
+			return r.msg
+		}
+		msgs[r.i] = r.msg
+	}
+	return msgs
+}
+
+// collect waits for the commands of a batch or a sequence returned by a
+// command run with All, and returns their messages. The internal messages of
+// commands that are handled by the program are resolved: the message of a
+// Latest command is returned unless it's stale, and debounced and throttled
+// commands are handed to the program.
+func (p *Program) collect(ctx context.Context, msg Msg, orError bool) Msg {
+	switch msg := msg.(type) {
+	case latestResultMsg:
+		if !p.isLatest(msg) {
+			return nil
+		}
+		return p.collect(ctx, msg.msg, orError)
+
+	case debounceMsg, throttleMsg:
+		p.Send(msg)
+		return nil
+
+	case BatchMsg:
+		return p.runAll(ctx, allMsg{cmds: msg, orError: orError})
+
+	case sequenceMsg:
+		msgs := make(AllMsg, len(msg))
+		for i, cmd := range msg {
+			if cmd == nil {
+				continue
+			}
+			msgs[i] = p.collect(ctx, p.runLimited(ctx, cmd), orError)
+			if _, ok := msgs[i].(error); ok && orError {
+				return msgs[i]
+			}
+		}
+		return msgs
+	}
+	return msg
+}
+
+// runRetry runs a command created with Retry.
+func (p *Program) runRetry(ctx context.Context, msg retryMsg) Msg {
+	policy := msg.policy
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+
+	for attempt := 1; ; attempt++ {
+		res := p.runCmd(ctx, msg.cmd)
+		if attempt >= policy.MaxAttempts || !policy.Failed(res) {
+			return res
+		}
+
+		d := policy.delay(attempt, rnd.Float64())
+		if policy.ReportAttempts {
+			retry := RetryMsg{
+				Attempt:     attempt,
+				MaxAttempts: policy.MaxAttempts,
+				Msg:         res,
+				Delay:       d,
+			}
+			select {
+			case <-ctx.Done():
+				return res
+			case p.msgs <- retry:
+			}
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return res
+		case <-t.C:
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package tea
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -105,3 +110,361 @@ func TestBatch(t *testing.T) {
 		}
 	})
 }
+
+// recordModel records the messages it receives.
+type recordModel struct {
+	mtx  sync.Mutex
+	msgs []Msg
+}
+
+func (m *recordModel) Init() Cmd { return nil }
+
+func (m *recordModel) Update(msg Msg) (Model, Cmd) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.msgs = append(m.msgs, msg)
+	return m, nil
+}
+
+func (m *recordModel) View() string { return "" }
+
+func (m *recordModel) received() []Msg {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]Msg(nil), m.msgs...)
+}
+
+// runCmds sends the given messages to a program with a recordModel, waits
+// for the given duration and returns the messages the model received.
+func runCmds(t *testing.T, wait time.Duration, msgs ...Msg) []Msg {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &recordModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	go func() {
+		for _, msg := range msgs {
+			p.Send(msg)
+		}
+		time.Sleep(wait)
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return m.received()
+}
+
+func msgCmd(msg Msg) Cmd {
+	return func() Msg {
+		return msg
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	d := 20 * time.Millisecond
+	msgs := runCmds(t, 100*time.Millisecond,
+		Debounce("a", d, msgCmd("a1"))(),
+		Debounce("b", d, msgCmd("b1"))(),
+		Debounce("a", d, msgCmd("a2"))(),
+	)
+
+	if len(msgs) != 2 || !containsMsg(msgs, "a2") || !containsMsg(msgs, "b1") {
+		t.Errorf("expected the last command of each debounce to run, got %v", msgs)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	d := 50 * time.Millisecond
+	msgs := runCmds(t, 150*time.Millisecond,
+		Throttle("a", d, msgCmd("1"))(),
+		Throttle("a", d, msgCmd("2"))(),
+		Throttle("a", d, msgCmd("3"))(),
+	)
+
+	if expected := []Msg{"1", "3"}; !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected the first and last throttled commands to run, got %v", msgs)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	slow := CmdContext(func(ctx context.Context) Msg {
+		<-ctx.Done()
+		return "slow"
+	})
+	msgs := runCmds(t, 50*time.Millisecond,
+		BatchMsg{
+			WithTimeout(slow, 10*time.Millisecond, "timeout"),
+			WithTimeout(msgCmd("fast"), time.Second, "timeout"),
+		},
+	)
+
+	if len(msgs) != 2 || !containsMsg(msgs, "timeout") || !containsMsg(msgs, "fast") {
+		t.Errorf("expected a timeout and a fast message, got %v", msgs)
+	}
+}
+
+func TestWithTimeoutPanic(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &recordModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithPanicMsgs())
+	go func() {
+		p.Send(WithTimeout(func() Msg { panic("boom") }, 10*time.Millisecond, "timeout")())
+		time.Sleep(50 * time.Millisecond)
+		p.Quit()
+	}()
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := m.received()
+	if len(msgs) != 1 {
+		t.Fatalf("expected only a PanicMsg, got %v", msgs)
+	}
+	if _, ok := msgs[0].(PanicMsg); !ok {
+		t.Errorf("expected a PanicMsg, got %v", msgs[0])
+	}
+}
+
+func containsMsg(msgs []Msg, msg Msg) bool {
+	for _, m := range msgs {
+		if reflect.DeepEqual(m, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLatest(t *testing.T) {
+	t.Run("cancels older commands", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		started, cancelled := make(chan struct{}), make(chan struct{})
+		slow := CmdContext(func(ctx context.Context) Msg {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return "slow"
+		})
+
+		m := &recordModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+		go func() {
+			p.Send(Latest("search", slow)())
+			<-started
+			p.Send(Latest("search", msgCmd("fast"))())
+			<-cancelled
+			time.Sleep(20 * time.Millisecond)
+			p.Quit()
+		}()
+
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if expected := []Msg{"fast"}; !reflect.DeepEqual(m.received(), expected) {
+			t.Errorf("expected %v, got %v", expected, m.received())
+		}
+	})
+
+	t.Run("drops older commands", func(t *testing.T) {
+		older := Latest("search", msgCmd("older"))
+		newer := Latest("search", msgCmd("newer"))
+		msgs := runCmds(t, 50*time.Millisecond, newer(), older())
+
+		// The older message may only arrive before the newer one runs.
+		if len(msgs) == 0 || msgs[len(msgs)-1] != "newer" {
+			t.Errorf("expected the newer message last, got %v", msgs)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		msgs := runCmds(t, 50*time.Millisecond,
+			Latest("a", msgCmd("a"))(),
+			Latest("b", msgCmd("b"))(),
+		)
+
+		if len(msgs) != 2 || !containsMsg(msgs, "a") || !containsMsg(msgs, "b") {
+			t.Errorf("expected both messages, got %v", msgs)
+		}
+	})
+}
+
+type testErrMsg struct{}
+
+func (testErrMsg) Error() string { return "error" }
+
+func TestAll(t *testing.T) {
+	slow := func(msg Msg) Cmd {
+		return func() Msg {
+			time.Sleep(10 * time.Millisecond)
+			return msg
+		}
+	}
+
+	t.Run("in order", func(t *testing.T) {
+		msgs := runCmds(t, 50*time.Millisecond,
+			All(slow("a"), msgCmd("b"), nil, Batch(msgCmd("c"), slow("d")), Sequence(slow("e"), msgCmd("f")))(),
+		)
+
+		expected := []Msg{AllMsg{"a", "b", nil, AllMsg{"c", "d"}, AllMsg{"e", "f"}}}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	})
+
+	t.Run("or error", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		wait := CmdContext(func(ctx context.Context) Msg {
+			<-ctx.Done()
+			close(cancelled)
+			return "cancelled"
+		})
+		msgs := runCmds(t, 50*time.Millisecond,
+			AllOrError(wait, slow(testErrMsg{}), msgCmd("a"))(),
+		)
+
+		if expected := []Msg{testErrMsg{}}; !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("expected the other commands to be cancelled")
+		}
+	})
+
+	t.Run("in a sequence", func(t *testing.T) {
+		msgs := runCmds(t, 50*time.Millisecond,
+			Sequence(All(slow("a"), msgCmd("b")), msgCmd("c"))(),
+		)
+
+		expected := []Msg{AllMsg{"a", "b"}, "c"}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	})
+
+	t.Run("latest and debounce", func(t *testing.T) {
+		msgs := runCmds(t, 50*time.Millisecond,
+			All(Latest("k", msgCmd("a")), Debounce("d", time.Millisecond, msgCmd("b")))(),
+		)
+
+		if !containsMsg(msgs, AllMsg{"a", nil}) || !containsMsg(msgs, "b") {
+			t.Errorf("expected the latest message in the AllMsg and the debounced one on its own, got %v", msgs)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		var running, maxRunning int32
+		limited := func(msg Msg) Cmd {
+			return func() Msg {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				if n > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, n)
+				}
+				time.Sleep(5 * time.Millisecond)
+				return msg
+			}
+		}
+
+		m := &recordModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMaxConcurrentCommands(1))
+		go func() {
+			p.Send(Sequence(All(limited("a"), limited("b")), All(limited("c"), Batch(limited("d"), limited("e"))))())
+			time.Sleep(100 * time.Millisecond)
+			p.Quit()
+		}()
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Msg{AllMsg{"a", "b"}, AllMsg{"c", AllMsg{"d", "e"}}}
+		if msgs := m.received(); !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+		if n := atomic.LoadInt32(&maxRunning); n != 1 {
+			t.Errorf("expected 1 command at a time, got %d", n)
+		}
+	})
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		Delay:    100 * time.Millisecond,
+		MaxDelay: time.Second,
+		Jitter:   0.5,
+	}.withDefaults()
+
+	tests := []struct {
+		attempt  int
+		rnd      float64
+		expected time.Duration
+	}{
+		{1, 0, 100 * time.Millisecond},
+		{2, 0, 200 * time.Millisecond},
+		{3, 0, 400 * time.Millisecond},
+		{5, 0, time.Second},
+		{2, 0.5, 150 * time.Millisecond},
+		{2, 0.999, 100*time.Millisecond + 100*time.Microsecond},
+	}
+	for _, test := range tests {
+		if d := policy.delay(test.attempt, test.rnd); d != test.expected {
+			t.Errorf("attempt %d with jitter %v: expected a delay of %v, got %v", test.attempt, test.rnd, test.expected, d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	flaky := func(failures int) Cmd {
+		var attempts int32
+		return func() Msg {
+			if int(atomic.AddInt32(&attempts, 1)) <= failures {
+				return testErrMsg{}
+			}
+			return "ok"
+		}
+	}
+	policy := RetryPolicy{Delay: time.Millisecond, ReportAttempts: true}
+
+	t.Run("succeeds", func(t *testing.T) {
+		msgs := runCmds(t, 50*time.Millisecond, Retry(flaky(2), policy)())
+		if len(msgs) != 3 || msgs[2] != "ok" {
+			t.Fatalf("expected two retries and a success, got %v", msgs)
+		}
+		for i, msg := range msgs[:2] {
+			r, ok := msg.(RetryMsg)
+			if !ok || r.Attempt != i+1 || r.MaxAttempts != 3 || r.Msg != (testErrMsg{}) {
+				t.Errorf("expected a RetryMsg for attempt %d, got %#v", i+1, msg)
+			}
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		policy := policy
+		policy.ReportAttempts = false
+		msgs := runCmds(t, 50*time.Millisecond, Retry(flaky(5), policy)())
+		if expected := []Msg{testErrMsg{}}; !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	})
+
+	t.Run("failure predicate", func(t *testing.T) {
+		policy := policy
+		policy.ReportAttempts = false
+		policy.Failed = func(msg Msg) bool { return msg != "ok" }
+		msgs := runCmds(t, 50*time.Millisecond, Retry(flaky(1), policy)())
+		if expected := []Msg{"ok"}; !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	})
+}
@@ -2,13 +2,9 @@ package main
 
 // This example illustrates how to debounce commands.
 //
-// When the user presses a key we increment the "tag" value on the model and,
-// after a short delay, we include that tag value in the message produced
-// by the Tick command.
-//
-// In a subsequent Update, if the tag in the Msg matches current tag on the
-// model's state we know that the debouncing is complete and we can proceed as
-// normal. If not, we simply ignore the inbound message.
+// When the user presses a key we return a debounced command. Every key press
+// restarts the debounce timer, so the command only runs once the user has
+// stopped pressing keys for a second.
 
 import (
 	"fmt"
@@ -20,10 +16,8 @@ import (
 
 const debounceDuration = time.Second
 
-type exitMsg int
-
 type model struct {
-	tag int
+	presses int
 }
 
 func (m model) Init() tea.Cmd {
@@ -31,30 +25,21 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
+	switch msg.(type) {
 	case tea.KeyMsg:
-		// Increment the tag on the model...
-		m.tag++
-		return m, tea.Tick(debounceDuration, func(_ time.Time) tea.Msg {
-			// ...and include a copy of that tag value in the message.
-			return exitMsg(m.tag)
-		})
-	case exitMsg:
-		// If the tag in the message doesn't match the tag on the model then we
-		// know that this message was not the last one sent and another is on
-		// the way. If that's the case we know, we can ignore this message.
-		// Otherwise, the debounce timeout has passed and this message is a
-		// valid debounced one.
-		if int(msg) == m.tag {
-			return m, tea.Quit
-		}
+		m.presses++
+
+		// Quit once no key was pressed for a second. Debounced commands
+		// with the same ID replace each other, and the program keeps track
+		// of the timer.
+		return m, tea.Debounce("exit", debounceDuration, tea.Quit)
 	}
 
 	return m, nil
 }
 
 func (m model) View() string {
-	return fmt.Sprintf("Key presses: %d", m.tag) +
+	return fmt.Sprintf("Key presses: %d", m.presses) +
 		"\nTo exit press any key, then wait for one second without pressing anything."
 }
 
@@ -0,0 +1,50 @@
+package tea
+
+import "bytes"
+
+// FocusMsg is sent to the program's update function when the terminal gains
+// focus. Focus reporting must be enabled with the WithReportFocus
+// ProgramOption or the EnableReportFocus command for it to be sent.
+type FocusMsg struct{}
+
+// BlurMsg is sent to the program's update function when the terminal loses
+// focus. Focus reporting must be enabled with the WithReportFocus
+// ProgramOption or the EnableReportFocus command for it to be sent.
+type BlurMsg struct{}
+
+// Sequences to enable and disable focus reporting (mode 1004).
+const (
+	enableReportFocusSeq  = "\x1b[?1004h"
+	disableReportFocusSeq = "\x1b[?1004l"
+)
+
+// Sequences the terminal sends when it gains and loses focus.
+const (
+	focusInSeq  = "\x1b[I"
+	focusOutSeq = "\x1b[O"
+)
+
+// detectReportFocus detects a focus event sent by the terminal while focus
+// reporting was enabled.
+//
+// Note: this function is a no-op if focus reporting was not enabled on the
+// terminal, since in that case we'd never see these particular escape
+// sequences.
+func detectReportFocus(input []byte) (hasRF bool, width int, msg Msg) {
+	switch {
+	case bytes.HasPrefix(input, []byte(focusInSeq)):
+		return true, len(focusInSeq), FocusMsg{}
+
+	case bytes.HasPrefix(input, []byte(focusOutSeq)):
+		// Some terminals send arrow keys as ESC [ O followed by a letter.
+		// Those take precedence.
+		if len(input) > len(focusOutSeq) {
+			if _, ok := sequences[string(input[:len(focusOutSeq)+1])]; ok {
+				return false, 0, nil
+			}
+		}
+		return true, len(focusOutSeq), BlurMsg{}
+	}
+
+	return false, 0, nil
+}
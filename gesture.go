@@ -0,0 +1,220 @@
+package tea
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default gesture settings.
+const (
+	defaultClickInterval = 500 * time.Millisecond
+	defaultDragThreshold = 1
+	defaultWheelInterval = 50 * time.Millisecond
+	defaultMaxWheelSpeed = 8
+)
+
+// MouseGestures configures the mouse gestures recognized when they're enabled
+// with WithMouseGestures. Zero values are replaced with defaults.
+type MouseGestures struct {
+	// ClickInterval is the longest time between two clicks for them to
+	// count as a double or triple click. It defaults to 500ms.
+	ClickInterval time.Duration
+
+	// DragThreshold is the distance, in cells, the mouse has to move with a
+	// button held down for a drag to start. It defaults to 1.
+	DragThreshold int
+
+	// WheelInterval is the longest time between two wheel events in the same
+	// direction for the wheel to accelerate. It defaults to 50ms.
+	WheelInterval time.Duration
+
+	// MaxWheelSpeed is the largest Delta of a MouseWheelMsg. It defaults to
+	// 8. Set it to 1 to disable wheel acceleration.
+	MaxWheelSpeed int
+}
+
+// MouseClickMsg is sent when a mouse button is pressed and released without
+// dragging, if mouse gestures are enabled. It follows the MouseMsg of the
+// release.
+type MouseClickMsg struct {
+	// MouseEvent is the release that completed the click, with the button
+	// that was clicked.
+	MouseEvent
+
+	// Count is the number of clicks in a row at the same position: 1 for a
+	// single click, 2 for a double click, 3 for a triple click and so on.
+	Count int
+}
+
+// String returns a string representation of a click.
+func (m MouseClickMsg) String() string {
+	btn := mouseButtons[m.Button]
+	switch m.Count {
+	case 1:
+		return btn + " click"
+	case 2: //nolint:gomnd
+		return btn + " double click"
+	case 3: //nolint:gomnd
+		return btn + " triple click"
+	default:
+		return fmt.Sprintf("%s click (%d)", btn, m.Count)
+	}
+}
+
+// MouseDrag describes a step of a drag gesture.
+type MouseDrag struct {
+	// MouseEvent is the mouse event of this step of the drag, with the
+	// button being dragged.
+	MouseEvent
+
+	// OriginX and OriginY are the position where the button was pressed.
+	OriginX int
+	OriginY int
+
+	// DeltaX and DeltaY are the distance the mouse moved since the previous
+	// step of the drag, or since the origin for the start of the drag.
+	DeltaX int
+	DeltaY int
+}
+
+// MouseDragStartMsg is sent when the mouse moves past the drag threshold with
+// a button held down, if mouse gestures are enabled.
+type MouseDragStartMsg MouseDrag
+
+// MouseDragMoveMsg is sent when the mouse moves during a drag, if mouse
+// gestures are enabled.
+type MouseDragMoveMsg MouseDrag
+
+// MouseDragEndMsg is sent when the button is released at the end of a drag, if
+// mouse gestures are enabled.
+type MouseDragEndMsg MouseDrag
+
+// MouseWheelMsg is sent for every wheel event if mouse gestures are enabled,
+// following its MouseMsg. When the wheel turns quickly, Delta grows to scroll
+// faster.
+type MouseWheelMsg struct {
+	MouseEvent
+
+	// Delta is the number of steps the wheel event is worth, 1 unless the
+	// wheel is accelerating.
+	Delta int
+}
+
+// gestureRecognizer synthesizes gesture messages from mouse events.
+type gestureRecognizer struct {
+	MouseGestures
+
+	// the button being held down, where it was pressed, and where it was
+	// during the last step of the drag, if any
+	pressed          bool
+	button           MouseButton
+	originX, originY int
+	lastX, lastY     int
+	dragging         bool
+
+	// the last click, for counting clicks in a row
+	clickCount     int
+	clickTime      time.Time
+	clickButton    MouseButton
+	clickX, clickY int
+
+	// the last wheel event, for acceleration
+	wheelTime   time.Time
+	wheelButton MouseButton
+	wheelSpeed  int
+}
+
+func newGestureRecognizer(g MouseGestures) *gestureRecognizer {
+	if g.ClickInterval <= 0 {
+		g.ClickInterval = defaultClickInterval
+	}
+	if g.DragThreshold <= 0 {
+		g.DragThreshold = defaultDragThreshold
+	}
+	if g.WheelInterval <= 0 {
+		g.WheelInterval = defaultWheelInterval
+	}
+	if g.MaxWheelSpeed <= 0 {
+		g.MaxWheelSpeed = defaultMaxWheelSpeed
+	}
+	return &gestureRecognizer{MouseGestures: g}
+}
+
+// handle returns the gesture messages that a mouse event, received at the
+// given time, produces.
+func (g *gestureRecognizer) handle(m MouseEvent, now time.Time) []Msg {
+	switch {
+	case m.IsWheel():
+		if m.Button == g.wheelButton && now.Sub(g.wheelTime) <= g.WheelInterval {
+			if g.wheelSpeed < g.MaxWheelSpeed {
+				g.wheelSpeed++
+			}
+		} else {
+			g.wheelSpeed = 1
+		}
+		g.wheelButton, g.wheelTime = m.Button, now
+		return []Msg{MouseWheelMsg{MouseEvent: m, Delta: g.wheelSpeed}}
+
+	case m.Action == MouseActionPress:
+		g.pressed, g.dragging = true, false
+		g.button = m.Button
+		g.originX, g.originY = m.X, m.Y
+		g.lastX, g.lastY = m.X, m.Y
+
+	case m.Action == MouseActionMotion && g.pressed:
+		m.Button = g.button
+		if !g.dragging {
+			if abs(m.X-g.originX) < g.DragThreshold && abs(m.Y-g.originY) < g.DragThreshold {
+				return nil
+			}
+			g.dragging = true
+			return []Msg{MouseDragStartMsg(g.drag(m))}
+		}
+		if m.X == g.lastX && m.Y == g.lastY {
+			return nil
+		}
+		return []Msg{MouseDragMoveMsg(g.drag(m))}
+
+	case m.Action == MouseActionRelease && g.pressed:
+		// Releases don't always say which button was released.
+		m.Button = g.button
+		g.pressed = false
+		if g.dragging {
+			g.dragging = false
+			return []Msg{MouseDragEndMsg(g.drag(m))}
+		}
+
+		if g.clickCount > 0 && m.Button == g.clickButton &&
+			m.X == g.clickX && m.Y == g.clickY &&
+			now.Sub(g.clickTime) <= g.ClickInterval {
+			g.clickCount++
+		} else {
+			g.clickCount = 1
+		}
+		g.clickButton, g.clickTime = m.Button, now
+		g.clickX, g.clickY = m.X, m.Y
+		return []Msg{MouseClickMsg{MouseEvent: m, Count: g.clickCount}}
+	}
+
+	return nil
+}
+
+// drag returns the current step of the drag and makes it the last one.
+func (g *gestureRecognizer) drag(m MouseEvent) MouseDrag {
+	d := MouseDrag{
+		MouseEvent: m,
+		OriginX:    g.originX,
+		OriginY:    g.originY,
+		DeltaX:     m.X - g.lastX,
+		DeltaY:     m.Y - g.lastY,
+	}
+	g.lastX, g.lastY = m.X, m.Y
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,148 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGestureClicks(t *testing.T) {
+	g := newGestureRecognizer(MouseGestures{ClickInterval: 100 * time.Millisecond})
+	start := time.Now()
+
+	click := func(at time.Duration, x int) []Msg {
+		g.handle(MouseEvent{X: x, Button: MouseButtonLeft, Action: MouseActionPress}, start.Add(at))
+		// X10 releases don't say which button was released.
+		return g.handle(MouseEvent{X: x, Action: MouseActionRelease}, start.Add(at))
+	}
+	expect := func(msgs []Msg, x, count int) {
+		t.Helper()
+		expected := []Msg{MouseClickMsg{
+			MouseEvent: MouseEvent{X: x, Button: MouseButtonLeft, Action: MouseActionRelease},
+			Count:      count,
+		}}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	}
+
+	expect(click(0, 0), 0, 1)
+	expect(click(50*time.Millisecond, 0), 0, 2)
+	expect(click(100*time.Millisecond, 0), 0, 3)
+
+	// Too late.
+	expect(click(300*time.Millisecond, 0), 0, 1)
+
+	// Somewhere else.
+	expect(click(310*time.Millisecond, 1), 1, 1)
+
+	if s := (MouseClickMsg{MouseEvent: MouseEvent{Button: MouseButtonLeft}, Count: 2}).String(); s != "left double click" {
+		t.Errorf("expected left double click, got %q", s)
+	}
+}
+
+func TestGestureDrag(t *testing.T) {
+	g := newGestureRecognizer(MouseGestures{DragThreshold: 2})
+	now := time.Now()
+
+	events := []MouseEvent{
+		{X: 1, Y: 1, Button: MouseButtonLeft, Action: MouseActionPress},
+		{X: 2, Y: 1, Button: MouseButtonLeft, Action: MouseActionMotion},
+		{X: 3, Y: 2, Button: MouseButtonLeft, Action: MouseActionMotion},
+		{X: 3, Y: 2, Button: MouseButtonLeft, Action: MouseActionMotion},
+		{X: 5, Y: 1, Button: MouseButtonLeft, Action: MouseActionMotion},
+		{X: 5, Y: 1, Action: MouseActionRelease},
+	}
+	var msgs []Msg
+	for _, e := range events {
+		msgs = append(msgs, g.handle(e, now)...)
+	}
+
+	drag := func(x, y, dx, dy int, action MouseAction) MouseDrag {
+		return MouseDrag{
+			MouseEvent: MouseEvent{X: x, Y: y, Button: MouseButtonLeft, Action: action},
+			OriginX:    1,
+			OriginY:    1,
+			DeltaX:     dx,
+			DeltaY:     dy,
+		}
+	}
+	expected := []Msg{
+		MouseDragStartMsg(drag(3, 2, 2, 1, MouseActionMotion)),
+		MouseDragMoveMsg(drag(5, 1, 2, -1, MouseActionMotion)),
+		MouseDragEndMsg(drag(5, 1, 0, 0, MouseActionRelease)),
+	}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected:\n%#v\ngot:\n%#v", expected, msgs)
+	}
+}
+
+func TestGestureWheel(t *testing.T) {
+	g := newGestureRecognizer(MouseGestures{WheelInterval: 10 * time.Millisecond, MaxWheelSpeed: 3})
+	start := time.Now()
+
+	var deltas []int
+	for _, e := range []struct {
+		at     time.Duration
+		button MouseButton
+	}{
+		{0, MouseButtonWheelDown},
+		{5 * time.Millisecond, MouseButtonWheelDown},
+		{10 * time.Millisecond, MouseButtonWheelDown},
+		{15 * time.Millisecond, MouseButtonWheelDown},
+		{20 * time.Millisecond, MouseButtonWheelUp},
+		{100 * time.Millisecond, MouseButtonWheelUp},
+	} {
+		msgs := g.handle(MouseEvent{Button: e.button}, start.Add(e.at))
+		deltas = append(deltas, msgs[0].(MouseWheelMsg).Delta)
+	}
+
+	if expected := []int{1, 2, 3, 3, 1, 1}; !reflect.DeepEqual(deltas, expected) {
+		t.Errorf("expected deltas %v, got %v", expected, deltas)
+	}
+}
+
+type gestureTestModel struct {
+	msgs []Msg
+}
+
+func (m *gestureTestModel) Init() Cmd {
+	return nil
+}
+
+func (m *gestureTestModel) Update(msg Msg) (Model, Cmd) {
+	switch msg.(type) {
+	case MouseMsg, MouseClickMsg:
+		m.msgs = append(m.msgs, msg)
+	}
+	if _, ok := msg.(MouseClickMsg); ok {
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m *gestureTestModel) View() string {
+	return ""
+}
+
+func TestTeaMouseGestures(t *testing.T) {
+	var buf bytes.Buffer
+	in := bytes.NewBufferString("\x1b[<0;3;2M\x1b[<0;3;2m")
+
+	m := &gestureTestModel{}
+	p := NewProgram(m, WithInput(in), WithOutput(&buf), WithMouseGestures(MouseGestures{}))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	release := MouseEvent{X: 2, Y: 1, Button: MouseButtonLeft, Action: MouseActionRelease, Type: MouseRelease}
+	expected := []Msg{
+		MouseMsg{X: 2, Y: 1, Button: MouseButtonLeft, Action: MouseActionPress, Type: MouseLeft},
+		MouseMsg(release),
+		MouseClickMsg{MouseEvent: release, Count: 1},
+	}
+	if !reflect.DeepEqual(m.msgs, expected) {
+		t.Errorf("expected:\n%#v\ngot:\n%#v", expected, m.msgs)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -56,6 +57,27 @@ type Key struct {
 	Runes []rune
 	Alt   bool
 	Paste bool
+
+	// Mod contains the modifier keys that were held during the key event,
+	// including the ones implied by Type, such as ctrl for KeyCtrlC, and alt
+	// when Alt is set. Most terminals report shift, alt and ctrl for special
+	// keys; terminals that support the kitty keyboard protocol report all
+	// modifiers for all keys once enabled with the WithKeyboardEnhancements
+	// ProgramOption.
+	Mod KeyMod
+
+	// EventType is the type of the key event. Unless event types are
+	// reported with the KeyboardReportEventTypes enhancement, it's always
+	// KeyPress.
+	EventType KeyEventType
+
+	// ShiftedRune is the rune the key produces when shift is held and
+	// BaseRune is the rune of the key in the standard PC-101 layout,
+	// regardless of the active keyboard layout. They're only reported with
+	// the KeyboardReportAlternateKeys enhancement, and only when they differ
+	// from the key itself.
+	ShiftedRune rune
+	BaseRune    rune
 }
 
 // String returns a friendly string representation for a key. It's safe (and
@@ -64,12 +86,23 @@ type Key struct {
 //	k := Key{Type: KeyEnter}
 //	fmt.Println(k)
 //	// Output: enter
+//
+// Modifiers are always listed in the same order, before the name of the key:
+// alt, ctrl, shift, meta, hyper and super. Shift is left out for runes, since
+// it's already reflected by the runes themselves.
+//
+// Note that the event type isn't part of the string, so a key release has the
+// same string representation as a key press.
 func (k Key) String() (str string) {
-	var buf strings.Builder
+	mod := k.Mod &^ (ModCapsLock | ModNumLock)
 	if k.Alt {
-		buf.WriteString("alt+")
+		mod |= ModAlt
 	}
-	if k.Type == KeyRunes {
+
+	var name string
+	switch k.Type {
+	case KeyRunes:
+		var buf strings.Builder
 		if k.Paste {
 			// Note: bubbles/keys bindings currently do string compares to
 			// recognize shortcuts. Since pasted text should never activate
@@ -83,12 +116,105 @@ func (k Key) String() (str string) {
 		if k.Paste {
 			buf.WriteByte(']')
 		}
-		return buf.String()
-	} else if s, ok := keyNames[k.Type]; ok {
-		buf.WriteString(s)
-		return buf.String()
+		name = buf.String()
+		mod &^= ModShift
+	default:
+		s, ok := keyNames[k.Type]
+		if !ok {
+			return ""
+		}
+		if k.Type == KeySpace {
+			mod &^= ModShift
+		}
+
+		// Some key types imply modifiers, such as KeyCtrlUp. Take them
+		// apart so all modifiers are listed in the same order.
+		var implied KeyMod
+		name, implied = splitKeyName(s)
+		mod |= implied
+	}
+
+	var buf strings.Builder
+	for _, m := range keyModNames {
+		if mod&m.mod != 0 {
+			buf.WriteString(m.name)
+			buf.WriteByte('+')
+		}
+	}
+	buf.WriteString(name)
+	return buf.String()
+}
+
+// KeyMod represents the modifier keys held during a key event. Modifiers are
+// treated as bits.
+type KeyMod int
+
+// Modifier keys.
+const (
+	ModShift KeyMod = 1 << iota
+	ModAlt
+	ModCtrl
+	ModSuper
+	ModHyper
+	ModMeta
+	ModCapsLock
+	ModNumLock
+)
+
+// keyModNames contains the names of the modifiers, in the order they're listed
+// in the string representation of a key. Lock keys aren't listed.
+var keyModNames = []struct {
+	mod  KeyMod
+	name string
+}{
+	{ModAlt, "alt"},
+	{ModCtrl, "ctrl"},
+	{ModShift, "shift"},
+	{ModMeta, "meta"},
+	{ModHyper, "hyper"},
+	{ModSuper, "super"},
+}
+
+// splitKeyName splits the name of a key into the name of the key without
+// modifiers and the modifiers the name contains.
+func splitKeyName(s string) (name string, mod KeyMod) {
+	for {
+		found := false
+		for _, m := range keyModNames {
+			prefix := m.name + "+"
+			if len(s) > len(prefix) && strings.HasPrefix(s, prefix) {
+				s = s[len(prefix):]
+				mod |= m.mod
+				found = true
+			}
+		}
+		if !found {
+			return s, mod
+		}
+	}
+}
+
+// KeyEventType indicates whether a key was pressed, repeated or released.
+type KeyEventType int
+
+// Key event types.
+const (
+	KeyPress KeyEventType = iota
+	KeyRepeat
+	KeyRelease
+)
+
+func (e KeyEventType) String() string {
+	switch e {
+	case KeyPress:
+		return "press"
+	case KeyRepeat:
+		return "repeat"
+	case KeyRelease:
+		return "release"
+	default:
+		return ""
 	}
-	return ""
 }
 
 // KeyType indicates the key pressed, such as KeyEnter or KeyBreak or KeyCtrlC.
@@ -114,6 +240,23 @@ func (k KeyType) String() (str string) {
 	return ""
 }
 
+// Base returns the key type without the modifiers it implies, such as KeyUp
+// for KeyCtrlShiftUp. Key types that don't imply modifiers, as well as
+// control keys like KeyCtrlA, are returned as is.
+//
+// Together with Key.Mod, it can be used to match a key regardless of the
+// modifiers held:
+//
+//	if msg.Type.Base() == tea.KeyUp && msg.Mod&tea.ModCtrl != 0 {
+//	    // ctrl+up, ctrl+shift+up, ctrl+alt+up...
+//	}
+func (k KeyType) Base() KeyType {
+	if t, ok := baseKeyTypes[k]; ok {
+		return t
+	}
+	return k
+}
+
 // Control keys. We could do this with an iota, but the values are very
 // specific, so we set the values explicitly to avoid any confusion.
 //
@@ -201,6 +344,11 @@ const (
 )
 
 // Other keys.
+//
+// Some of these key types combine a key with modifiers, such as KeyCtrlUp and
+// KeyShiftTab. They predate Key.Mod and are kept as aliases for compatibility:
+// keys are still reported with these types, and the modifiers they imply are
+// also set in Key.Mod. Use KeyType.Base to get the key without modifiers.
 const (
 	KeyRunes KeyType = -(iota + 1)
 	KeyUp
@@ -257,6 +405,75 @@ const (
 	KeyF20
 )
 
+// modifiedKey is a key type along with modifiers.
+type modifiedKey struct {
+	typ KeyType
+	mod KeyMod
+}
+
+// modifiedKeyTypes maps keys with modifiers to the key types that combine
+// them, such as KeyCtrlUp.
+var modifiedKeyTypes = map[modifiedKey]KeyType{
+	{KeyTab, ModShift}:             KeyShiftTab,
+	{KeyPgUp, ModCtrl}:             KeyCtrlPgUp,
+	{KeyPgDown, ModCtrl}:           KeyCtrlPgDown,
+	{KeyUp, ModCtrl}:               KeyCtrlUp,
+	{KeyDown, ModCtrl}:             KeyCtrlDown,
+	{KeyRight, ModCtrl}:            KeyCtrlRight,
+	{KeyLeft, ModCtrl}:             KeyCtrlLeft,
+	{KeyHome, ModCtrl}:             KeyCtrlHome,
+	{KeyEnd, ModCtrl}:              KeyCtrlEnd,
+	{KeyUp, ModShift}:              KeyShiftUp,
+	{KeyDown, ModShift}:            KeyShiftDown,
+	{KeyRight, ModShift}:           KeyShiftRight,
+	{KeyLeft, ModShift}:            KeyShiftLeft,
+	{KeyHome, ModShift}:            KeyShiftHome,
+	{KeyEnd, ModShift}:             KeyShiftEnd,
+	{KeyUp, ModCtrl | ModShift}:    KeyCtrlShiftUp,
+	{KeyDown, ModCtrl | ModShift}:  KeyCtrlShiftDown,
+	{KeyLeft, ModCtrl | ModShift}:  KeyCtrlShiftLeft,
+	{KeyRight, ModCtrl | ModShift}: KeyCtrlShiftRight,
+	{KeyHome, ModCtrl | ModShift}:  KeyCtrlShiftHome,
+	{KeyEnd, ModCtrl | ModShift}:   KeyCtrlShiftEnd,
+}
+
+// modifiedKeyType returns the key type that combines the given key type with
+// the ctrl and shift modifiers, if there's one. It's the inverse of
+// KeyType.Base.
+func modifiedKeyType(t KeyType, mod KeyMod) KeyType {
+	if mt, ok := modifiedKeyTypes[modifiedKey{t, mod & (ModCtrl | ModShift)}]; ok {
+		return mt
+	}
+	return t
+}
+
+// baseKeyTypes maps the key types that combine a key with modifiers to the
+// key without modifiers.
+var baseKeyTypes = func() map[KeyType]KeyType {
+	m := make(map[KeyType]KeyType, len(modifiedKeyTypes))
+	for k, t := range modifiedKeyTypes {
+		m[t] = k.typ
+	}
+	return m
+}()
+
+// impliedKeyMod returns the modifiers implied by a key type, such as ctrl for
+// KeyCtrlA and ctrl and shift for KeyCtrlShiftUp.
+func impliedKeyMod(t KeyType) KeyMod {
+	_, mod := splitKeyName(keyNames[t])
+	return mod
+}
+
+// withMod returns the key with the modifiers implied by its type and by Alt
+// added to Mod.
+func (k Key) withMod() Key {
+	k.Mod |= impliedKeyMod(k.Type)
+	if k.Alt {
+		k.Mod |= ModAlt
+	}
+	return k
+}
+
 // Mappings for control keys and other special keys to friendly consts.
 var keyNames = map[KeyType]string{
 	// Control keys.
@@ -555,33 +772,75 @@ var spaceRunes = []rune{' '}
 
 // readAnsiInputs reads keypress and mouse inputs from a TTY and produces messages
 // containing information about the key or mouse events accordingly.
-func readAnsiInputs(ctx context.Context, msgs chan<- Msg, input io.Reader) error {
+//
+// If escTimeout is positive, input that ends with what may be the start of an
+// escape sequence, such as a lone escape character, isn't interpreted until
+// escTimeout has passed without more input arriving, so that slow escape
+// sequences aren't split.
+func readAnsiInputs(ctx context.Context, msgs chan<- Msg, input io.Reader, escTimeout time.Duration) error {
 	var buf [256]byte
 
+	read := func() ([]byte, error) {
+		n, err := input.Read(buf[:])
+		return buf[:n], err
+	}
+
+	// Waiting for more input can only time out if reads happen in the
+	// background.
+	var reads <-chan inputRead
+	if escTimeout > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		reads = readInBackground(input, len(buf), done)
+		read = func() ([]byte, error) {
+			r := <-reads
+			return r.b, r.err
+		}
+	}
+
 	var leftOverFromPrevIteration []byte
 loop:
 	for {
 		// Read and block.
-		numBytes, err := input.Read(buf[:])
+		data, err := read()
 		if err != nil {
 			return fmt.Errorf("error reading input: %w", err)
 		}
-		b := buf[:numBytes]
+		b := data
 		if leftOverFromPrevIteration != nil {
 			b = append(leftOverFromPrevIteration, b...)
 		}
 
-		// If we had a short read (numBytes < len(buf)), we're sure that
+		// If we had a short read (len(data) < len(buf)), we're sure that
 		// the end of this read is an event boundary, so there is no doubt
 		// if we are encountering the end of the buffer while parsing a message.
 		// However, if we've succeeded in filling up the buffer, there may
 		// be more data in the OS buffer ready to be read in, to complete
 		// the last message in the input. In that case, we will retry with
 		// the left over data in the next iteration.
-		canHaveMoreData := numBytes == len(buf)
+		canHaveMoreData := len(data) == len(buf)
 
 		var i, w int
 		for i, w = 0, 0; i < len(b); i += w {
+			if reads != nil && !canHaveMoreData && isPendingEscape(b[i:]) {
+				// The rest of the input may be the start of an escape
+				// sequence. Give the terminal some time to send the
+				// rest of it before deciding.
+				select {
+				case r := <-reads:
+					if r.err != nil {
+						return fmt.Errorf("error reading input: %w", r.err)
+					}
+					b = append(append([]byte(nil), b[i:]...), r.b...)
+					canHaveMoreData = len(r.b) == len(buf)
+					i, w = 0, 0
+					continue
+				case <-time.After(escTimeout):
+				case <-ctx.Done():
+					return fmt.Errorf("found context error while reading input: %w", ctx.Err())
+				}
+			}
+
 			var msg Msg
 			w, msg = detectOneMsg(b[i:], canHaveMoreData)
 			if w == 0 {
@@ -606,6 +865,68 @@ loop:
 	}
 }
 
+// inputRead is the result of reading from the input.
+type inputRead struct {
+	b   []byte
+	err error
+}
+
+// readInBackground reads from the input in a goroutine, in reads of up to size
+// bytes, until reading fails or done is closed.
+func readInBackground(input io.Reader, size int, done <-chan struct{}) <-chan inputRead {
+	reads := make(chan inputRead)
+	go func() {
+		buf := make([]byte, size)
+		for {
+			n, err := input.Read(buf)
+			select {
+			case reads <- inputRead{b: append([]byte(nil), buf[:n]...), err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return reads
+}
+
+// isPendingEscape reports whether b is the start of an escape sequence that
+// may continue in input that hasn't arrived yet: a lone escape character, or
+// the beginning of a sequence without its final byte.
+func isPendingEscape(b []byte) bool {
+	if len(b) == 0 || b[0] != '\x1b' {
+		return false
+	}
+
+	switch {
+	case len(b) == 1:
+		return true
+	case b[1] == '\x1b':
+		// An escape sequence with the alt modifier.
+		return isPendingEscape(b[1:])
+	case b[1] == 'O':
+		return len(b) == 2
+	case b[1] == '[':
+		// X10 mouse events have a fixed length.
+		if len(b) > 2 && b[2] == 'M' {
+			return len(b) < 6 //nolint:gomnd
+		}
+
+		// CSI sequences end with a byte in the range 0x40-0x7e, after
+		// parameter and intermediate bytes in the range 0x20-0x3f.
+		for _, c := range b[2:] {
+			if c < 0x20 || c > 0x3f {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
 var (
 	unknownCSIRe  = regexp.MustCompile(`^\x1b\[[\x30-\x3f]*[\x20-\x2f]*[\x40-\x7e]`)
 	mouseSGRRegex = regexp.MustCompile(`(\d+);(\d+);(\d+)([Mm])`)
@@ -628,6 +949,11 @@ func detectOneMsg(b []byte, canHaveMoreData bool) (w int, msg Msg) {
 		}
 	}
 
+	// Detect replies to mode requests.
+	if w, msg := detectModeReport(b); w > 0 {
+		return w, msg
+	}
+
 	// Detect bracketed paste.
 	var foundbp bool
 	foundbp, w, msg = detectBracketedPaste(b)
@@ -635,6 +961,13 @@ func detectOneMsg(b []byte, canHaveMoreData bool) (w int, msg Msg) {
 		return
 	}
 
+	// Detect focus events.
+	var foundRF bool
+	foundRF, w, msg = detectReportFocus(b)
+	if foundRF {
+		return
+	}
+
 	// Detect escape sequence and control characters other than NUL,
 	// possibly with an escape character in front to mark the Alt
 	// modifier.
@@ -655,7 +988,7 @@ func detectOneMsg(b []byte, canHaveMoreData bool) (w int, msg Msg) {
 
 	// Are we seeing a standalone NUL? This is not handled by detectSequence().
 	if i < len(b) && b[i] == 0 {
-		return i + 1, KeyMsg{Type: keyNUL, Alt: alt}
+		return i + 1, KeyMsg(Key{Type: keyNUL, Alt: alt}.withMod())
 	}
 
 	// Find the longest sequence of runes that are not control
@@ -692,7 +1025,7 @@ func detectOneMsg(b []byte, canHaveMoreData bool) (w int, msg Msg) {
 		if len(runes) == 1 && runes[0] == ' ' {
 			k.Type = KeySpace
 		}
-		return i, KeyMsg(k)
+		return i, KeyMsg(k.withMod())
 	}
 
 	// We didn't find an escape sequence, nor a valid rune. Was this a
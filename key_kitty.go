@@ -0,0 +1,257 @@
+package tea
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// KeyboardEnhancements are the progressive enhancement flags of the kitty
+// keyboard protocol. Each flag makes the terminal report more information
+// about key events. Flags are treated as bits.
+//
+// See: https://sw.kovidgoyal.net/kitty/keyboard-protocol/#progressive-enhancement
+type KeyboardEnhancements int
+
+// Keyboard enhancement flags.
+const (
+	// KeyboardDisambiguateEscapeCodes makes the terminal report keys that
+	// are otherwise ambiguous, such as esc, alt+key and ctrl+i (as opposed
+	// to tab), with unambiguous escape codes.
+	KeyboardDisambiguateEscapeCodes KeyboardEnhancements = 1 << iota
+
+	// KeyboardReportEventTypes makes the terminal report key repeat and
+	// release events in addition to key presses.
+	KeyboardReportEventTypes
+
+	// KeyboardReportAlternateKeys makes the terminal report the shifted key
+	// and the key in the standard PC-101 layout along with the key.
+	KeyboardReportAlternateKeys
+
+	// KeyboardReportAllKeys makes the terminal report all keys, including
+	// enter, tab, backspace and plain text keys, as escape codes.
+	KeyboardReportAllKeys
+
+	// KeyboardReportAssociatedText makes the terminal report the text a key
+	// produces along with the key. It only has an effect together with
+	// KeyboardReportAllKeys.
+	KeyboardReportAssociatedText
+)
+
+// pushKeyboardEnhancements returns the sequence that pushes the given flags
+// onto the terminal's stack of keyboard enhancement flags.
+func pushKeyboardEnhancements(flags KeyboardEnhancements) string {
+	return "\x1b[>" + strconv.Itoa(int(flags)) + "u"
+}
+
+// popKeyboardEnhancements pops the flags pushed with pushKeyboardEnhancements,
+// restoring the previous flags.
+const popKeyboardEnhancements = "\x1b[<u"
+
+// kittyKeys maps the key codes of the kitty keyboard protocol to key types.
+var kittyKeys = map[int]KeyType{
+	27:  KeyEscape,
+	13:  KeyEnter,
+	9:   KeyTab,
+	127: KeyBackspace,
+	32:  KeySpace,
+
+	57376: KeyF13,
+	57377: KeyF14,
+	57378: KeyF15,
+	57379: KeyF16,
+	57380: KeyF17,
+	57381: KeyF18,
+	57382: KeyF19,
+	57383: KeyF20,
+
+	// Keypad.
+	57414: KeyEnter,
+	57417: KeyLeft,
+	57418: KeyRight,
+	57419: KeyUp,
+	57420: KeyDown,
+	57421: KeyPgUp,
+	57422: KeyPgDown,
+	57423: KeyHome,
+	57424: KeyEnd,
+	57425: KeyInsert,
+	57426: KeyDelete,
+}
+
+// kittyKeypadRunes maps the key codes of keypad keys that produce text to
+// their runes.
+var kittyKeypadRunes = map[int]rune{
+	57399: '0',
+	57400: '1',
+	57401: '2',
+	57402: '3',
+	57403: '4',
+	57404: '5',
+	57405: '6',
+	57406: '7',
+	57407: '8',
+	57408: '9',
+	57409: '.',
+	57410: '/',
+	57411: '*',
+	57412: '-',
+	57413: '+',
+	57415: '=',
+	57416: ',',
+}
+
+// ctrlKeys maps runes to the control keys terminals send for them when ctrl
+// is held. Runes whose control key is also a named key, such as i (tab), are
+// left out: the kitty keyboard protocol reports them unambiguously, so they
+// stay runes with ModCtrl.
+var ctrlKeys = func() map[rune]KeyType {
+	m := map[rune]KeyType{
+		'@':  KeyCtrlAt,
+		'\\': KeyCtrlBackslash,
+		']':  KeyCtrlCloseBracket,
+		'^':  KeyCtrlCaret,
+		'_':  KeyCtrlUnderscore,
+	}
+	for r := 'a'; r <= 'z'; r++ {
+		if r == 'i' || r == 'm' {
+			continue
+		}
+		m[r] = KeyCtrlA + KeyType(r-'a')
+	}
+	return m
+}()
+
+// detectCSIKey detects key events reported as CSI sequences with parameters,
+// such as keys with modifiers in the xterm encoding, or keys sent by
+// terminals with kitty keyboard protocol enhancements enabled:
+//
+//	CSI code[:shifted[:base]] [; modifiers[:event] [; text]] u
+//	CSI [number] [; modifiers[:event]] final
+//
+// The second form is used for functional keys such as the arrow keys, where
+// the final byte and number identify the key as in the legacy sequences.
+func detectCSIKey(input []byte) (w int, msg Msg) {
+	loc := unknownCSIRe.FindIndex(input)
+	if loc == nil {
+		return 0, nil
+	}
+	seq := string(input[2 : loc[1]-1])
+	final := input[loc[1]-1]
+	if strings.Trim(seq, "0123456789;:") != "" {
+		return 0, nil
+	}
+
+	params := strings.Split(seq, ";")
+	if len(params) > 3 || (len(params) == 3 && final != 'u') {
+		return 0, nil
+	}
+	param := func(i, j int) (int, bool) {
+		if i >= len(params) {
+			return 0, false
+		}
+		sub := strings.Split(params[i], ":")
+		if j >= len(sub) || sub[j] == "" {
+			return 0, false
+		}
+		n, err := strconv.Atoi(sub[j])
+		return n, err == nil
+	}
+
+	var k Key
+	if mods, ok := param(1, 0); ok && mods > 1 {
+		k.Mod = KeyMod(mods - 1)
+
+		// The kitty keyboard protocol extends the xterm modifier
+		// parameter, except that xterm reports meta where kitty reports
+		// super. Sequences without kitty's extensions are xterm's.
+		if final != 'u' && !strings.Contains(seq, ":") && k.Mod < ModHyper && k.Mod&ModSuper != 0 {
+			k.Mod = k.Mod&^ModSuper | ModMeta
+		}
+	}
+	if event, ok := param(1, 1); ok && event > 1 {
+		k.EventType = KeyEventType(event - 1)
+	}
+	k.Alt = k.Mod&ModAlt != 0
+	mod := k.Mod &^ (ModCapsLock | ModNumLock)
+
+	if final != 'u' {
+		// Functional keys are identified by their legacy sequence
+		// without parameters.
+		var legacy string
+		n, _ := param(0, 0)
+		switch {
+		case final == '~':
+			legacy = "\x1b[" + strconv.Itoa(n) + "~"
+		case final >= 'P' && final <= 'S':
+			legacy = "\x1bO" + string(final)
+		case n <= 1:
+			legacy = "\x1b[" + string(final)
+		}
+		base, ok := sequences[legacy]
+		if legacy == "" || !ok || base.Alt {
+			return 0, nil
+		}
+		k.Type = modifiedKeyType(base.Type, mod)
+		return loc[1], KeyMsg(k)
+	}
+
+	code, ok := param(0, 0)
+	if !ok {
+		return 0, nil
+	}
+	if shifted, ok := param(0, 1); ok {
+		k.ShiftedRune = rune(shifted)
+	}
+	if base, ok := param(0, 2); ok {
+		k.BaseRune = rune(base)
+	}
+
+	if t, ok := kittyKeys[code]; ok {
+		k.Type = modifiedKeyType(t, mod)
+		if t == KeySpace {
+			// Terminals send ctrl+space as ctrl+@.
+			if mod&ModCtrl != 0 {
+				k.Type = KeyCtrlAt
+			} else {
+				k.Runes = spaceRunes
+			}
+		}
+		return loc[1], KeyMsg(k)
+	}
+
+	r, ok := kittyKeypadRunes[code]
+	if !ok {
+		if code >= 57344 && code <= 63743 {
+			// Other keys in the private use area, such as media and
+			// modifier keys, have no key type.
+			return loc[1], unknownCSISequenceMsg(input[:loc[1]])
+		}
+		r = rune(code)
+	}
+
+	if ct, ok := ctrlKeys[r]; ok && mod&ModCtrl != 0 {
+		k.Type = ct
+		return loc[1], KeyMsg(k)
+	}
+
+	k.Type = KeyRunes
+	switch {
+	case len(params) == 3:
+		// The text the key produces.
+		for _, s := range strings.Split(params[2], ":") {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return 0, nil
+			}
+			k.Runes = append(k.Runes, rune(n))
+		}
+	case mod&ModShift != 0 && k.ShiftedRune != 0:
+		k.Runes = []rune{k.ShiftedRune}
+	case mod&ModShift != 0:
+		k.Runes = []rune{unicode.ToUpper(r)}
+	default:
+		k.Runes = []rune{r}
+	}
+	return loc[1], KeyMsg(k)
+}
@@ -15,25 +15,25 @@ var extSequences = func() map[string]Key {
 	s := map[string]Key{}
 	for seq, key := range sequences {
 		key := key
-		s[seq] = key
+		s[seq] = key.withMod()
 		if !key.Alt {
 			key.Alt = true
-			s["\x1b"+seq] = key
+			s["\x1b"+seq] = key.withMod()
 		}
 	}
 	for i := keyNUL + 1; i <= keyDEL; i++ {
 		if i == keyESC {
 			continue
 		}
-		s[string([]byte{byte(i)})] = Key{Type: i}
-		s[string([]byte{'\x1b', byte(i)})] = Key{Type: i, Alt: true}
+		s[string([]byte{byte(i)})] = Key{Type: i}.withMod()
+		s[string([]byte{'\x1b', byte(i)})] = Key{Type: i, Alt: true}.withMod()
 		if i == keyUS {
 			i = keyDEL - 1
 		}
 	}
 	s[" "] = Key{Type: KeySpace, Runes: spaceRunes}
-	s["\x1b "] = Key{Type: KeySpace, Alt: true, Runes: spaceRunes}
-	s["\x1b\x1b"] = Key{Type: KeyEscape, Alt: true}
+	s["\x1b "] = Key{Type: KeySpace, Alt: true, Runes: spaceRunes, Mod: ModAlt}
+	s["\x1b\x1b"] = Key{Type: KeyEscape, Alt: true, Mod: ModAlt}
 	return s
 }()
 
@@ -66,6 +66,12 @@ func detectSequence(input []byte) (hasSeq bool, width int, msg Msg) {
 			return true, sz, KeyMsg(key)
 		}
 	}
+	// Is this a key with parameters we don't have a mapping for, such as a
+	// key event of the kitty keyboard protocol?
+	if w, msg := detectCSIKey(input); w > 0 {
+		return true, w, msg
+	}
+
 	// Is this an unknown CSI sequence?
 	if loc := unknownCSIRe.FindIndex(input); loc != nil {
 		return true, loc[1], unknownCSISequenceMsg(input[:loc[1]])
@@ -36,6 +36,25 @@ func TestKeyString(t *testing.T) {
 		}
 	})
 
+	t.Run("modifiers", func(t *testing.T) {
+		for _, tc := range []struct {
+			key      Key
+			expected string
+		}{
+			{Key{Type: KeyUp, Mod: ModCtrl | ModAlt}, "alt+ctrl+up"},
+			{Key{Type: KeyCtrlUp, Alt: true}, "alt+ctrl+up"},
+			{Key{Type: KeyCtrlA, Mod: ModCtrl | ModShift}, "ctrl+shift+a"},
+			{Key{Type: KeyRunes, Runes: []rune("i"), Mod: ModCtrl}, "ctrl+i"},
+			{Key{Type: KeyRunes, Runes: []rune("A"), Mod: ModShift | ModCapsLock}, "A"},
+			{Key{Type: KeyDelete, Mod: ModShift | ModSuper | ModHyper | ModMeta}, "shift+meta+hyper+super+delete"},
+			{Key{Type: KeyEnter, EventType: KeyRelease}, "enter"},
+		} {
+			if got := tc.key.String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		}
+	})
+
 	t.Run("invalid", func(t *testing.T) {
 		if got := KeyMsg(Key{
 			Type: KeyType(99999),
@@ -59,6 +78,22 @@ func TestKeyTypeString(t *testing.T) {
 	})
 }
 
+func TestKeyTypeBase(t *testing.T) {
+	for _, tc := range []struct {
+		typ, base KeyType
+	}{
+		{KeyCtrlShiftUp, KeyUp},
+		{KeyShiftTab, KeyTab},
+		{KeyCtrlPgDown, KeyPgDown},
+		{KeyUp, KeyUp},
+		{KeyCtrlA, KeyCtrlA},
+	} {
+		if got := tc.typ.Base(); got != tc.base {
+			t.Errorf("expected base of %s to be %s, got %s", tc.typ, tc.base, got)
+		}
+	}
+}
+
 type seqTest struct {
 	seq []byte
 	msg Msg
@@ -70,9 +105,14 @@ func buildBaseSeqTests() []seqTest {
 	td := []seqTest{}
 	for seq, key := range sequences {
 		key := key
+		key.Mod = impliedKeyMod(key.Type)
+		if key.Alt {
+			key.Mod |= ModAlt
+		}
 		td = append(td, seqTest{[]byte(seq), KeyMsg(key)})
 		if !key.Alt {
 			key.Alt = true
+			key.Mod |= ModAlt
 			td = append(td, seqTest{[]byte("\x1b" + seq), KeyMsg(key)})
 		}
 	}
@@ -83,8 +123,8 @@ func buildBaseSeqTests() []seqTest {
 			// suite.
 			continue
 		}
-		td = append(td, seqTest{[]byte{byte(i)}, KeyMsg{Type: i}})
-		td = append(td, seqTest{[]byte{'\x1b', byte(i)}, KeyMsg{Type: i, Alt: true}})
+		td = append(td, seqTest{[]byte{byte(i)}, KeyMsg{Type: i, Mod: impliedKeyMod(i)}})
+		td = append(td, seqTest{[]byte{'\x1b', byte(i)}, KeyMsg{Type: i, Alt: true, Mod: impliedKeyMod(i) | ModAlt}})
 		if i == keyUS {
 			i = keyDEL - 1
 		}
@@ -105,7 +145,7 @@ func buildBaseSeqTests() []seqTest {
 		// An escape character with the alt modifier.
 		seqTest{
 			[]byte{'\x1b', ' '},
-			KeyMsg{Type: KeySpace, Runes: []rune(" "), Alt: true},
+			KeyMsg{Type: KeySpace, Runes: []rune(" "), Alt: true, Mod: ModAlt},
 		},
 	)
 	return td
@@ -151,7 +191,7 @@ func TestDetectOneMsg(t *testing.T) {
 		},
 		seqTest{
 			[]byte{'\x1b', 'a'},
-			KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true},
+			KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true, Mod: ModAlt},
 		},
 		seqTest{
 			[]byte{'a', 'a', 'a'},
@@ -164,7 +204,7 @@ func TestDetectOneMsg(t *testing.T) {
 		},
 		seqTest{
 			[]byte("\x1b☃"),
-			KeyMsg{Type: KeyRunes, Runes: []rune("☃"), Alt: true},
+			KeyMsg{Type: KeyRunes, Runes: []rune("☃"), Alt: true, Mod: ModAlt},
 		},
 		// Standalone control chacters.
 		seqTest{
@@ -173,19 +213,107 @@ func TestDetectOneMsg(t *testing.T) {
 		},
 		seqTest{
 			[]byte{byte(keySOH)},
-			KeyMsg{Type: KeyCtrlA},
+			KeyMsg{Type: KeyCtrlA, Mod: ModCtrl},
 		},
 		seqTest{
 			[]byte{'\x1b', byte(keySOH)},
-			KeyMsg{Type: KeyCtrlA, Alt: true},
+			KeyMsg{Type: KeyCtrlA, Alt: true, Mod: ModAlt | ModCtrl},
 		},
 		seqTest{
 			[]byte{byte(keyNUL)},
-			KeyMsg{Type: KeyCtrlAt},
+			KeyMsg{Type: KeyCtrlAt, Mod: ModCtrl},
 		},
 		seqTest{
 			[]byte{'\x1b', byte(keyNUL)},
-			KeyMsg{Type: KeyCtrlAt, Alt: true},
+			KeyMsg{Type: KeyCtrlAt, Alt: true, Mod: ModAlt | ModCtrl},
+		},
+		// Focus events.
+		seqTest{
+			[]byte("\x1b[I"),
+			FocusMsg{},
+		},
+		seqTest{
+			[]byte("\x1b[O"),
+			BlurMsg{},
+		},
+		// Kitty keyboard protocol.
+		seqTest{
+			[]byte("\x1b[27u"),
+			KeyMsg{Type: KeyEscape},
+		},
+		seqTest{
+			[]byte("\x1b[105;5u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("i"), Mod: ModCtrl},
+		},
+		seqTest{
+			[]byte("\x1b[99;5u"),
+			KeyMsg{Type: KeyCtrlC, Mod: ModCtrl},
+		},
+		seqTest{
+			[]byte("\x1b[97;3u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true, Mod: ModAlt},
+		},
+		seqTest{
+			[]byte("\x1b[97:65;2u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("A"), Mod: ModShift, ShiftedRune: 'A'},
+		},
+		seqTest{
+			[]byte("\x1b[1092::97;1:2u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("ф"), EventType: KeyRepeat, BaseRune: 'a'},
+		},
+		seqTest{
+			[]byte("\x1b[97;;97u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+		},
+		seqTest{
+			[]byte("\x1b[13;1:3u"),
+			KeyMsg{Type: KeyEnter, EventType: KeyRelease},
+		},
+		seqTest{
+			[]byte("\x1b[9;2u"),
+			KeyMsg{Type: KeyShiftTab, Mod: ModShift},
+		},
+		seqTest{
+			[]byte("\x1b[57399u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("0")},
+		},
+		seqTest{
+			[]byte("\x1b[57441;2u"),
+			unknownCSISequenceMsg("\x1b[57441;2u"),
+		},
+		seqTest{
+			[]byte("\x1b[1;5:3A"),
+			KeyMsg{Type: KeyCtrlUp, Mod: ModCtrl, EventType: KeyRelease},
+		},
+		seqTest{
+			[]byte("\x1b[6;2~"),
+			KeyMsg{Type: KeyPgDown, Mod: ModShift},
+		},
+		seqTest{
+			[]byte("\x1b[97;9u"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("a"), Mod: ModSuper},
+		},
+		// Modifiers in the xterm encoding.
+		seqTest{
+			[]byte("\x1b[1;9P"),
+			KeyMsg{Type: KeyF1, Mod: ModMeta},
+		},
+		seqTest{
+			[]byte("\x1b[1;8A"),
+			KeyMsg{Type: KeyCtrlShiftUp, Alt: true, Mod: ModAlt | ModCtrl | ModShift},
+		},
+		seqTest{
+			[]byte("\x1b[15;6~"),
+			KeyMsg{Type: KeyF5, Mod: ModCtrl | ModShift},
+		},
+		seqTest{
+			[]byte("\x1b[2;16~"),
+			KeyMsg{Type: KeyInsert, Alt: true, Mod: ModAlt | ModCtrl | ModShift | ModMeta},
+		},
+		// Mode report.
+		seqTest{
+			[]byte("\x1b[?2026;2$y"),
+			modeReportMsg{mode: modeSynchronizedOutput, setting: modeReset},
 		},
 		// Invalid characters.
 		seqTest{
@@ -218,7 +346,7 @@ func TestDetectOneMsg(t *testing.T) {
 
 func TestReadLongInput(t *testing.T) {
 	input := strings.Repeat("a", 1000)
-	msgs := testReadInputs(t, bytes.NewReader([]byte(input)))
+	msgs := testReadInputs(t, bytes.NewReader([]byte(input)), 0)
 	if len(msgs) != 1 {
 		t.Errorf("expected 1 messages, got %d", len(msgs))
 	}
@@ -264,14 +392,14 @@ func TestReadInput(t *testing.T) {
 			[]byte{'a', '\x1b', 'a'},
 			[]Msg{
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
-				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true},
+				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true, Mod: ModAlt},
 			},
 		},
 		{"a alt+a a",
 			[]byte{'a', '\x1b', 'a', 'a'},
 			[]Msg{
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
-				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true},
+				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true, Mod: ModAlt},
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
 			},
 		},
@@ -280,14 +408,15 @@ func TestReadInput(t *testing.T) {
 			[]Msg{
 				KeyMsg{
 					Type: KeyCtrlA,
+					Mod:  ModCtrl,
 				},
 			},
 		},
 		{"ctrl+a ctrl+b",
 			[]byte{byte(keySOH), byte(keySTX)},
 			[]Msg{
-				KeyMsg{Type: KeyCtrlA},
-				KeyMsg{Type: KeyCtrlB},
+				KeyMsg{Type: KeyCtrlA, Mod: ModCtrl},
+				KeyMsg{Type: KeyCtrlB, Mod: ModCtrl},
 			},
 		},
 		{"alt+a",
@@ -297,6 +426,7 @@ func TestReadInput(t *testing.T) {
 					Type:  KeyRunes,
 					Alt:   true,
 					Runes: []rune{'a'},
+					Mod:   ModAlt,
 				},
 			},
 		},
@@ -356,6 +486,7 @@ func TestReadInput(t *testing.T) {
 			[]Msg{
 				KeyMsg{
 					Type: KeyShiftTab,
+					Mod:  ModShift,
 				},
 			},
 		},
@@ -369,6 +500,7 @@ func TestReadInput(t *testing.T) {
 				KeyMsg{
 					Type: KeyEnter,
 					Alt:  true,
+					Mod:  ModAlt,
 				},
 			},
 		},
@@ -386,6 +518,7 @@ func TestReadInput(t *testing.T) {
 				KeyMsg{
 					Type: KeyCtrlA,
 					Alt:  true,
+					Mod:  ModAlt | ModCtrl,
 				},
 			},
 		},
@@ -412,27 +545,31 @@ func TestReadInput(t *testing.T) {
 		},
 		{"alt+enter",
 			[]byte{'\x1b', '\x0d'},
-			[]Msg{KeyMsg{Type: KeyEnter, Alt: true}},
+			[]Msg{KeyMsg{Type: KeyEnter, Alt: true, Mod: ModAlt}},
 		},
 		{"alt+backspace",
 			[]byte{'\x1b', '\x7f'},
-			[]Msg{KeyMsg{Type: KeyBackspace, Alt: true}},
+			[]Msg{KeyMsg{Type: KeyBackspace, Alt: true, Mod: ModAlt}},
 		},
 		{"ctrl+@",
 			[]byte{'\x00'},
-			[]Msg{KeyMsg{Type: KeyCtrlAt}},
+			[]Msg{KeyMsg{Type: KeyCtrlAt, Mod: ModCtrl}},
 		},
 		{"alt+ctrl+@",
 			[]byte{'\x1b', '\x00'},
-			[]Msg{KeyMsg{Type: KeyCtrlAt, Alt: true}},
+			[]Msg{KeyMsg{Type: KeyCtrlAt, Alt: true, Mod: ModAlt | ModCtrl}},
 		},
 		{"esc",
 			[]byte{'\x1b'},
 			[]Msg{KeyMsg{Type: KeyEsc}},
 		},
+		{"shift+up",
+			[]byte("\x1b[OA"),
+			[]Msg{KeyMsg{Type: KeyShiftUp, Mod: ModShift}},
+		},
 		{"alt+esc",
 			[]byte{'\x1b', '\x1b'},
-			[]Msg{KeyMsg{Type: KeyEsc, Alt: true}},
+			[]Msg{KeyMsg{Type: KeyEsc, Alt: true, Mod: ModAlt}},
 		},
 		{"[a b] o",
 			[]byte{
@@ -478,7 +615,7 @@ func TestReadInput(t *testing.T) {
 
 	for i, td := range testData {
 		t.Run(fmt.Sprintf("%d: %s", i, td.keyname), func(t *testing.T) {
-			msgs := testReadInputs(t, bytes.NewReader(td.in))
+			msgs := testReadInputs(t, bytes.NewReader(td.in), 0)
 			var buf strings.Builder
 			for i, msg := range msgs {
 				if i > 0 {
@@ -507,7 +644,89 @@ func TestReadInput(t *testing.T) {
 	}
 }
 
-func testReadInputs(t *testing.T, input io.Reader) []Msg {
+// slowReader returns one chunk per read, waiting before each one, like a
+// terminal over a slow connection.
+type slowReader struct {
+	chunks []string
+	delay  time.Duration
+}
+
+func (r *slowReader) Read(b []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := copy(b, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestReadInputEscTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []string
+		escTimeout time.Duration
+		out        []Msg
+	}{
+		{
+			"split sequence without timeout",
+			[]string{"\x1b", "[A"},
+			0,
+			[]Msg{
+				KeyMsg{Type: KeyEscape},
+				KeyMsg{Type: KeyRunes, Runes: []rune("[A")},
+			},
+		},
+		{
+			"split sequence",
+			[]string{"\x1b", "[A"},
+			time.Second,
+			[]Msg{KeyMsg{Type: KeyUp}},
+		},
+		{
+			"split sequence parameters",
+			[]string{"\x1b[1;", "5A"},
+			time.Second,
+			[]Msg{KeyMsg{Type: KeyCtrlUp, Mod: ModCtrl}},
+		},
+		{
+			"split alt",
+			[]string{"\x1b", "a"},
+			time.Second,
+			[]Msg{KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true, Mod: ModAlt}},
+		},
+		{
+			"escape",
+			[]string{"\x1b", "a"},
+			time.Millisecond,
+			[]Msg{
+				KeyMsg{Type: KeyEscape},
+				KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+			},
+		},
+		{
+			"complete sequence",
+			[]string{"\x1b[A", "a"},
+			time.Second,
+			[]Msg{
+				KeyMsg{Type: KeyUp},
+				KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &slowReader{chunks: tc.chunks, delay: 50 * time.Millisecond}
+			msgs := testReadInputs(t, r, tc.escTimeout)
+			if !reflect.DeepEqual(tc.out, msgs) {
+				t.Fatalf("expected:\n%#v\ngot:\n%#v", tc.out, msgs)
+			}
+		})
+	}
+}
+
+func testReadInputs(t *testing.T, input io.Reader, escTimeout time.Duration) []Msg {
 	// We'll check that the input reader finishes at the end
 	// without error.
 	var wg sync.WaitGroup
@@ -528,7 +747,7 @@ func testReadInputs(t *testing.T, input io.Reader) []Msg {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		inputErr = readAnsiInputs(ctx, msgsC, input)
+		inputErr = readAnsiInputs(ctx, msgsC, input, escTimeout)
 		msgsC <- nil
 	}()
 
@@ -7,18 +7,21 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/erikgeiser/coninput"
 	localereader "github.com/mattn/go-localereader"
 	"golang.org/x/sys/windows"
 )
 
-func readInputs(ctx context.Context, msgs chan<- Msg, input io.Reader) error {
+func readInputs(ctx context.Context, msgs chan<- Msg, input io.Reader, escTimeout time.Duration) error {
 	if coninReader, ok := input.(*conInputReader); ok {
+		// The console reports key events, so there are no escape
+		// sequences to wait for.
 		return readConInputs(ctx, msgs, coninReader.conin)
 	}
 
-	return readAnsiInputs(ctx, msgs, localereader.NewReader(input))
+	return readAnsiInputs(ctx, msgs, localereader.NewReader(input), escTimeout)
 }
 
 func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) error {
@@ -37,11 +40,13 @@ func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) e
 					continue
 				}
 
+				mod := keyMod(e.ControlKeyState)
 				for i := 0; i < int(e.RepeatCount); i++ {
 					msgs = append(msgs, KeyMsg{
-						Type:  keyType(e),
+						Type:  modifiedKeyType(keyType(e), mod),
 						Runes: []rune{e.Char},
-						Alt:   e.ControlKeyState.Contains(coninput.LEFT_ALT_PRESSED | coninput.RIGHT_ALT_PRESSED),
+						Alt:   mod&ModAlt != 0,
+						Mod:   mod,
 					})
 				}
 			case coninput.WindowBufferSizeEventRecord:
@@ -167,6 +172,27 @@ func mouseEvent(p coninput.ButtonState, e coninput.MouseEventRecord) MouseMsg {
 	return ev
 }
 
+// keyMod returns the modifiers of a console key event.
+func keyMod(s coninput.ControlKeyState) KeyMod {
+	var mod KeyMod
+	if s.Contains(coninput.SHIFT_PRESSED) {
+		mod |= ModShift
+	}
+	if s.Contains(coninput.LEFT_ALT_PRESSED | coninput.RIGHT_ALT_PRESSED) {
+		mod |= ModAlt
+	}
+	if s.Contains(coninput.LEFT_CTRL_PRESSED | coninput.RIGHT_CTRL_PRESSED) {
+		mod |= ModCtrl
+	}
+	if s.Contains(coninput.CAPSLOCK_ON) {
+		mod |= ModCapsLock
+	}
+	if s.Contains(coninput.NUMLOCK_ON) {
+		mod |= ModNumLock
+	}
+	return mod
+}
+
 func keyType(e coninput.KeyEventRecord) KeyType {
 	code := e.VirtualKeyCode
 
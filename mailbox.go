@@ -0,0 +1,198 @@
+package tea
+
+// CoalesceFunc reports whether a message can be dropped in favor of the next
+// message in the mailbox, because the next one supersedes it. See
+// WithCoalescing.
+type CoalesceFunc func(msg, next Msg) bool
+
+// CoalesceWindowSize coalesces consecutive WindowSizeMsgs, so that only the
+// latest size is sent to Update.
+func CoalesceWindowSize(msg, next Msg) bool {
+	_, ok := msg.(WindowSizeMsg)
+	_, nextOk := next.(WindowSizeMsg)
+	return ok && nextOk
+}
+
+// CoalesceMouseMotion coalesces consecutive mouse motion events with the same
+// buttons and modifiers held down, so that only the latest position is sent
+// to Update.
+func CoalesceMouseMotion(msg, next Msg) bool {
+	m, ok := msg.(MouseMsg)
+	n, nextOk := next.(MouseMsg)
+	return ok && nextOk &&
+		m.Action == MouseActionMotion && n.Action == MouseActionMotion &&
+		m.Button == n.Button && m.Alt == n.Alt && m.Ctrl == n.Ctrl && m.Shift == n.Shift
+}
+
+// TrySend sends a message to the main update function like Send, but never
+// blocks. It reports whether the message was sent: it's dropped if the
+// mailbox is full or if the program has exited.
+//
+// Unless the program was started with WithMailbox, the mailbox has no room
+// for messages, and TrySend only succeeds when the program is waiting for a
+// message.
+func (p *Program) TrySend(msg Msg) bool {
+	if p.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case p.msgs <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// coalesce drops a message in favor of the messages waiting in the mailbox
+// that supersede it, according to the coalescing rules. It returns the latest
+// of these messages, and the message that stopped the coalescing, if any.
+func (p *Program) coalesce(msg Msg) (latest, next Msg) {
+	if len(p.coalescing) == 0 {
+		return msg, nil
+	}
+
+	for {
+		select {
+		case next = <-p.msgs:
+		default:
+			return msg, nil
+		}
+		if !p.coalesces(msg, next) {
+			return msg, next
+		}
+		msg = next
+	}
+}
+
+// coalesces reports whether one of the coalescing rules drops msg in favor of
+// next.
+func (p *Program) coalesces(msg, next Msg) bool {
+	for _, rule := range p.coalescing {
+		if rule(msg, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// MsgPriority is the priority class of a message. When message priorities are
+// enabled with WithMessagePriorities, messages of a higher priority class are
+// sent to Update before the messages of lower ones that are already waiting.
+// Messages of the same class are sent in the order they arrived.
+type MsgPriority int
+
+// Message priority classes, from highest to lowest.
+const (
+	// PriorityInput is the priority of user input, such as keys, mouse
+	// events and pastes.
+	PriorityInput MsgPriority = iota
+
+	// PrioritySystem is the priority of messages about the terminal, such as
+	// WindowSizeMsg, FocusMsg and BlurMsg.
+	PrioritySystem
+
+	// PriorityDefault is the priority of all other messages, such as the
+	// messages of commands and ticks.
+	PriorityDefault
+
+	numPriorities = iota
+)
+
+// DefaultMsgPriority returns the priority class of a message used when message
+// priorities are enabled without a custom classification. Custom
+// classifications can fall back to it.
+func DefaultMsgPriority(msg Msg) MsgPriority {
+	switch msg.(type) {
+	case KeyMsg, MouseMsg, unknownInputByteMsg, unknownCSISequenceMsg:
+		return PriorityInput
+	case WindowSizeMsg, FocusMsg, BlurMsg, modeReportMsg:
+		return PrioritySystem
+	default:
+		return PriorityDefault
+	}
+}
+
+// maxPrioritized is the number of messages taken from the mailbox to be
+// sorted by priority, beyond the size of the mailbox. Messages beyond that
+// wait to be sent as usual.
+const maxPrioritized = 256
+
+// receive returns the next message to handle, or false if the program exited
+// or failed, with the error it failed with, if any.
+func (p *Program) receive() (Msg, bool, error) {
+	// Stop as soon as the program exits or fails, even if messages are still
+	// waiting, in the mailbox or in the priority queues.
+	select {
+	case <-p.ctx.Done():
+		return nil, false, nil
+	case err := <-p.errs:
+		return nil, false, err
+	default:
+	}
+
+	if p.classify == nil {
+		msg := p.pending
+		p.pending = nil
+		if msg == nil {
+			select {
+			case <-p.ctx.Done():
+				return nil, false, nil
+			case err := <-p.errs:
+				return nil, false, err
+			case msg = <-p.msgs:
+			}
+		}
+		msg, p.pending = p.coalesce(msg)
+		return msg, true, nil
+	}
+
+	if p.queued == 0 {
+		select {
+		case <-p.ctx.Done():
+			return nil, false, nil
+		case err := <-p.errs:
+			return nil, false, err
+		case msg := <-p.msgs:
+			p.prioritize(msg)
+		}
+	}
+
+	// Sort the messages that are waiting by priority.
+drain:
+	for p.queued < cap(p.msgs)+maxPrioritized {
+		select {
+		case msg := <-p.msgs:
+			p.prioritize(msg)
+		default:
+			break drain
+		}
+	}
+
+	for i := range p.queues {
+		q := p.queues[i]
+		if len(q) == 0 {
+			continue
+		}
+		msg, n := q[0], 1
+		for n < len(q) && p.coalesces(msg, q[n]) {
+			msg = q[n]
+			n++
+		}
+		p.queues[i] = q[n:]
+		p.queued -= n
+		return msg, true, nil
+	}
+	return nil, true, nil
+}
+
+// prioritize queues a message by its priority class.
+func (p *Program) prioritize(msg Msg) {
+	i := p.classify(msg)
+	if i < PriorityInput {
+		i = PriorityInput
+	} else if i > PriorityDefault {
+		i = PriorityDefault
+	}
+	p.queues[i] = append(p.queues[i], msg)
+	p.queued++
+}
@@ -0,0 +1,257 @@
+package tea
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrySend(t *testing.T) {
+	p := NewProgram(nil, WithMailbox(1))
+	if !p.TrySend(incrementMsg{}) {
+		t.Error("expected the message to be sent to the mailbox")
+	}
+	if p.TrySend(incrementMsg{}) {
+		t.Error("expected the message to be dropped when the mailbox is full")
+	}
+
+	<-p.msgs
+	p.cancel()
+	if p.TrySend(incrementMsg{}) {
+		t.Error("expected the message to be dropped when the program has exited")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	motion := func(x int) MouseMsg {
+		return MouseMsg{X: x, Action: MouseActionMotion, Button: MouseButtonLeft}
+	}
+	key := KeyMsg{Type: KeyEnter}
+
+	p := NewProgram(nil, WithMailbox(10), WithCoalescing(CoalesceWindowSize, CoalesceMouseMotion))
+	for _, msg := range []Msg{
+		WindowSizeMsg{Width: 2}, WindowSizeMsg{Width: 3}, key,
+		motion(1), motion(2), MouseMsg{X: 3, Action: MouseActionRelease}, motion(4),
+	} {
+		p.msgs <- msg
+	}
+
+	var msgs []Msg
+	msg, next := p.coalesce(WindowSizeMsg{Width: 1})
+	for msg != nil {
+		msgs = append(msgs, msg)
+		if next == nil {
+			select {
+			case next = <-p.msgs:
+			default:
+			}
+		}
+		if next == nil {
+			break
+		}
+		msg, next = p.coalesce(next)
+	}
+
+	expected := []Msg{WindowSizeMsg{Width: 3}, key, motion(2), MouseMsg{X: 3, Action: MouseActionRelease}, motion(4)}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected %v, got %v", expected, msgs)
+	}
+}
+
+type printModel struct {
+	testModel
+	p *Program
+}
+
+func (m *printModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(incrementMsg); ok {
+		// This would block forever without a mailbox.
+		m.p.Println("printed from Update")
+		return m, Quit
+	}
+	return m, nil
+}
+
+func TestMailboxPrintlnFromUpdate(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &printModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMailbox(8))
+	m.p = p
+	go p.Send(incrementMsg{})
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrintlnAfterExit(t *testing.T) {
+	p := NewProgram(nil, WithMailbox(1))
+	p.Kill()
+
+	// These would block forever if they didn't give up once the program
+	// exited.
+	p.Println("one")
+	p.Printf("%s", "two")
+}
+
+type cmdEveryUpdateModel struct {
+	testModel
+	once    sync.Once
+	updated chan struct{}
+}
+
+func (m *cmdEveryUpdateModel) Update(Msg) (Model, Cmd) {
+	m.once.Do(func() { close(m.updated) })
+	return m, func() Msg { return nil }
+}
+
+func TestMailboxKill(t *testing.T) {
+	for name, opts := range map[string][]ProgramOption{
+		"mailbox":    {WithMailbox(200)},
+		"priorities": {WithMailbox(200), WithMessagePriorities(nil)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var in bytes.Buffer
+
+			m := &cmdEveryUpdateModel{updated: make(chan struct{})}
+			p := NewProgram(m, append(opts, WithInput(&in), WithOutput(&buf))...)
+			for i := 0; i < 200; i++ {
+				p.Send(incrementMsg{})
+			}
+			go func() {
+				<-m.updated
+				p.Kill()
+			}()
+
+			errs := make(chan error, 1)
+			go func() {
+				_, err := p.Run()
+				errs <- err
+			}()
+			select {
+			case err := <-errs:
+				if !errors.Is(err, ErrProgramKilled) {
+					t.Errorf("expected ErrProgramKilled, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected the program to stop when killed with messages in the mailbox")
+			}
+		})
+	}
+}
+
+type panicOnceModel struct {
+	testModel
+	updates int
+}
+
+func (m *panicOnceModel) Update(Msg) (Model, Cmd) {
+	m.updates++
+	time.Sleep(time.Millisecond)
+	if m.updates == 1 {
+		return m, func() Msg { panic("boom") }
+	}
+	return m, nil
+}
+
+func TestMessagePrioritiesPanic(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &panicOnceModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMailbox(200), WithMessagePriorities(nil))
+	for i := 0; i < 200; i++ {
+		p.Send(incrementMsg{})
+	}
+
+	_, err := p.Run()
+	var perr *PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if m.updates == 200 {
+		t.Error("expected the program to fail before handling all the queued messages")
+	}
+}
+
+func TestMessagePriorities(t *testing.T) {
+	type progressMsg int
+	type cancelMsg struct{}
+
+	tests := []struct {
+		name     string
+		classify func(Msg) MsgPriority
+		msgs     []Msg
+		expected []Msg
+	}{
+		{
+			name: "default",
+			msgs: []Msg{
+				progressMsg(1), WindowSizeMsg{}, KeyMsg{Type: KeyUp}, progressMsg(2),
+				FocusMsg{}, KeyMsg{Type: KeyDown},
+			},
+			expected: []Msg{
+				KeyMsg{Type: KeyUp}, KeyMsg{Type: KeyDown}, WindowSizeMsg{}, FocusMsg{},
+				progressMsg(1), progressMsg(2),
+			},
+		},
+		{
+			name: "custom",
+			classify: func(msg Msg) MsgPriority {
+				if _, ok := msg.(cancelMsg); ok {
+					return PriorityInput
+				}
+				return DefaultMsgPriority(msg)
+			},
+			msgs:     []Msg{progressMsg(1), cancelMsg{}, KeyMsg{Type: KeyUp}},
+			expected: []Msg{cancelMsg{}, KeyMsg{Type: KeyUp}, progressMsg(1)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProgram(nil, WithMailbox(len(test.msgs)), WithMessagePriorities(test.classify))
+			for _, msg := range test.msgs {
+				p.msgs <- msg
+			}
+
+			var msgs []Msg
+			for range test.msgs {
+				msg, ok, err := p.receive()
+				if !ok || err != nil {
+					t.Fatalf("expected a message, got %v", err)
+				}
+				msgs = append(msgs, msg)
+			}
+			if !reflect.DeepEqual(msgs, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, msgs)
+			}
+		})
+	}
+}
+
+func TestMessagePrioritiesCoalescing(t *testing.T) {
+	p := NewProgram(nil,
+		WithMailbox(4),
+		WithMessagePriorities(nil),
+		WithCoalescing(CoalesceWindowSize),
+	)
+	for _, msg := range []Msg{WindowSizeMsg{Width: 1}, incrementMsg{}, WindowSizeMsg{Width: 2}, KeyMsg{Type: KeyUp}} {
+		p.msgs <- msg
+	}
+
+	var msgs []Msg
+	for i := 0; i < 3; i++ {
+		msg, _, _ := p.receive()
+		msgs = append(msgs, msg)
+	}
+	expected := []Msg{KeyMsg{Type: KeyUp}, WindowSizeMsg{Width: 2}, incrementMsg{}}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected %v, got %v", expected, msgs)
+	}
+}
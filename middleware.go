@@ -0,0 +1,47 @@
+package tea
+
+// UpdateFunc updates a model in response to a message, like the Update method
+// of a Model.
+type UpdateFunc func(Model, Msg) (Model, Cmd)
+
+// Middleware wraps the update of a program, to add behavior to it such as
+// logging, metrics or global key bindings. A middleware is given the next
+// update in the chain and returns a new update, which sees the message and the
+// model before the update, and the model and the command the next update
+// returns. It can change any of them, add commands, or skip the next update
+// altogether:
+//
+//	// Quit on ctrl+c, whatever the model does with keys.
+//	func quitOnCtrlC(next tea.UpdateFunc) tea.UpdateFunc {
+//	    return func(m tea.Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+//	        if msg, ok := msg.(tea.KeyMsg); ok && msg.Type == tea.KeyCtrlC {
+//	            return m, tea.Quit
+//	        }
+//	        return next(m, msg)
+//	    }
+//	}
+//
+//	// Log the messages and how long they took.
+//	func logUpdates(next tea.UpdateFunc) tea.UpdateFunc {
+//	    return func(m tea.Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+//	        start := time.Now()
+//	        m, cmd := next(m, msg)
+//	        log.Printf("%T: %v", msg, time.Since(start))
+//	        return m, cmd
+//	    }
+//	}
+//
+// Middlewares are set with WithMiddleware.
+type Middleware func(next UpdateFunc) UpdateFunc
+
+// chainMiddleware returns the update of a model wrapped in the given
+// middlewares, the first one being the outermost.
+func chainMiddleware(mws []Middleware) UpdateFunc {
+	update := func(m Model, msg Msg) (Model, Cmd) {
+		return m.Update(msg)
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		update = mws[i](update)
+	}
+	return update
+}
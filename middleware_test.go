@@ -0,0 +1,71 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestChainMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next UpdateFunc) UpdateFunc {
+			return func(m Model, msg Msg) (Model, Cmd) {
+				calls = append(calls, name+" before")
+				m, cmd := next(m, msg)
+				calls = append(calls, name+" after")
+				return m, cmd
+			}
+		}
+	}
+
+	m := &testModel{}
+	update := chainMiddleware([]Middleware{trace("outer"), trace("inner")})
+	update(m, incrementMsg{})
+
+	expected := []string{"outer before", "inner before", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+	if m.counter.Load() != 1 {
+		t.Errorf("expected the model to be updated once, got %v", m.counter.Load())
+	}
+}
+
+func TestTeaMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	// Swallow increments and quit on them instead.
+	quitOnIncrement := func(next UpdateFunc) UpdateFunc {
+		return func(m Model, msg Msg) (Model, Cmd) {
+			if _, ok := msg.(incrementMsg); ok {
+				return m, Quit
+			}
+			return next(m, msg)
+		}
+	}
+	// Add a command to every update.
+	var seen []Msg
+	record := func(next UpdateFunc) UpdateFunc {
+		return func(m Model, msg Msg) (Model, Cmd) {
+			seen = append(seen, msg)
+			m, cmd := next(m, msg)
+			return m, Batch(cmd, func() Msg { return nil })
+		}
+	}
+
+	m := &testModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMiddleware(record, quitOnIncrement))
+	go p.Send(incrementMsg{})
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if m.counter.Load() != nil {
+		t.Errorf("expected the model not to be updated, got %v", m.counter.Load())
+	}
+	if len(seen) == 0 || seen[0] != (incrementMsg{}) {
+		t.Errorf("expected the outer middleware to see the increment, got %v", seen)
+	}
+}
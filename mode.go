@@ -0,0 +1,70 @@
+package tea
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Terminal modes Bubble Tea queries with DECRQM.
+const (
+	// modeSynchronizedOutput is the private mode used to batch the output of
+	// a frame so the terminal can paint it in one go, avoiding tearing.
+	//
+	// See: https://gist.github.com/christianparpart/d8a62cc1ab659194337d73e399004036
+	modeSynchronizedOutput = 2026
+)
+
+// Sequences to begin and end a synchronized update (BSU and ESU).
+const (
+	beginSynchronizedUpdate = "\x1b[?2026h"
+	endSynchronizedUpdate   = "\x1b[?2026l"
+)
+
+// modeSetting is the setting of a terminal mode, as reported by the terminal
+// in reply to a DECRQM request.
+type modeSetting int
+
+// Mode settings as defined by DECRPM.
+const (
+	modeNotRecognized modeSetting = iota
+	modeSet
+	modeReset
+	modePermanentlySet
+	modePermanentlyReset
+)
+
+// modeReportMsg is reported by the input reader when the terminal replies to
+// a DECRQM request for a private mode.
+type modeReportMsg struct {
+	mode    int
+	setting modeSetting
+}
+
+// supported reports whether the terminal supports the reported mode, that is,
+// whether it can be set and reset.
+func (m modeReportMsg) supported() bool {
+	return m.setting == modeSet || m.setting == modeReset
+}
+
+// modeReportRe matches the reply to a DECRQM request for a private mode:
+//
+//	CSI ? Pd ; Ps $ y
+var modeReportRe = regexp.MustCompile(`^\x1b\[\?(\d+);(\d)\$y`)
+
+// detectModeReport detects a DECRPM reply to a DECRQM request.
+func detectModeReport(b []byte) (w int, msg Msg) {
+	matches := modeReportRe.FindSubmatch(b)
+	if matches == nil {
+		return 0, nil
+	}
+
+	mode, _ := strconv.Atoi(string(matches[1]))
+	setting, _ := strconv.Atoi(string(matches[2]))
+	return len(matches[0]), modeReportMsg{mode: mode, setting: modeSetting(setting)}
+}
+
+// requestMode returns the DECRQM sequence that asks the terminal to report the
+// setting of the given private mode.
+func requestMode(mode int) string {
+	return "\x1b[?" + strconv.Itoa(mode) + "$p"
+}
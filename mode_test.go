@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestModeReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		seq       string
+		supported bool
+	}{
+		{"not recognized", "\x1b[?2026;0$y", false},
+		{"set", "\x1b[?2026;1$y", true},
+		{"reset", "\x1b[?2026;2$y", true},
+		{"permanently set", "\x1b[?2026;3$y", false},
+		{"permanently reset", "\x1b[?2026;4$y", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, msg := detectModeReport([]byte(test.seq))
+			if w != len(test.seq) {
+				t.Fatalf("parser did not consume the entire input: got %d, expected %d", w, len(test.seq))
+			}
+			report, ok := msg.(modeReportMsg)
+			if !ok {
+				t.Fatalf("expected a modeReportMsg, got %T", msg)
+			}
+			if report.mode != modeSynchronizedOutput {
+				t.Errorf("expected mode %d, got %d", modeSynchronizedOutput, report.mode)
+			}
+			if report.supported() != test.supported {
+				t.Errorf("expected supported to be %t", test.supported)
+			}
+		})
+	}
+}
+
+func TestSynchronizedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithSynchronizedOutput())
+	go p.Send(Quit())
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\x1b[?25l\x1b[?2004h\x1b[?2026hsuccess\r\n\x1b[0D\x1b[?2026l\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l"
+	if buf.String() != expected {
+		t.Errorf("expected embedded sequence:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
@@ -1,6 +1,9 @@
 package tea
 
-import "strconv"
+import (
+	"strconv"
+	"strings"
+)
 
 // MouseMsg contains information about a mouse event and are sent to a programs
 // update function when mouse activity occurs. Note that the mouse must first
@@ -23,10 +26,56 @@ type MouseEvent struct {
 	Action MouseAction
 	Button MouseButton
 
+	// zones contains the IDs of the zones under the mouse, innermost first,
+	// separated by NUL characters. It's a string rather than a slice so
+	// that mouse events stay comparable.
+	zones string
+
 	// Deprecated: Use MouseAction & MouseButton instead.
 	Type MouseEventType
 }
 
+// Zones returns the IDs of the zones under the mouse, innermost first. See
+// Zone.
+func (m MouseMsg) Zones() []string {
+	return MouseEvent(m).Zones()
+}
+
+// InZone reports whether the mouse event happened in the zone with the given
+// ID. See Zone.
+func (m MouseMsg) InZone(id string) bool {
+	return MouseEvent(m).InZone(id)
+}
+
+// Zones returns the IDs of the zones under the mouse, innermost first. See
+// Zone.
+func (m MouseEvent) Zones() []string {
+	if m.zones == "" {
+		return nil
+	}
+	return strings.Split(m.zones, zoneSeparator)
+}
+
+// InZone reports whether the mouse event happened in the zone with the given
+// ID. See Zone.
+func (m MouseEvent) InZone(id string) bool {
+	for _, z := range m.Zones() {
+		if z == id {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneSeparator separates zone IDs in MouseEvent.zones.
+const zoneSeparator = "\x00"
+
+// withZones returns the mouse event with the given zone IDs.
+func (m MouseEvent) withZones(ids []string) MouseEvent {
+	m.zones = strings.Join(ids, zoneSeparator)
+	return m
+}
+
 // IsWheel returns true if the mouse event is a wheel event.
 func (m MouseEvent) IsWheel() bool {
 	return m.Button == MouseButtonWheelUp || m.Button == MouseButtonWheelDown ||
@@ -2,23 +2,27 @@ package tea
 
 type nilRenderer struct{}
 
-func (n nilRenderer) start()                     {}
-func (n nilRenderer) stop()                      {}
-func (n nilRenderer) kill()                      {}
-func (n nilRenderer) write(_ string)             {}
-func (n nilRenderer) repaint()                   {}
-func (n nilRenderer) clearScreen()               {}
-func (n nilRenderer) altScreen() bool            { return false }
-func (n nilRenderer) enterAltScreen()            {}
-func (n nilRenderer) exitAltScreen()             {}
-func (n nilRenderer) showCursor()                {}
-func (n nilRenderer) hideCursor()                {}
-func (n nilRenderer) enableMouseCellMotion()     {}
-func (n nilRenderer) disableMouseCellMotion()    {}
-func (n nilRenderer) enableMouseAllMotion()      {}
-func (n nilRenderer) disableMouseAllMotion()     {}
-func (n nilRenderer) enableBracketedPaste()      {}
-func (n nilRenderer) disableBracketedPaste()     {}
-func (n nilRenderer) enableMouseSGRMode()        {}
-func (n nilRenderer) disableMouseSGRMode()       {}
-func (n nilRenderer) bracketedPasteActive() bool { return false }
+func (n nilRenderer) Start()                     {}
+func (n nilRenderer) Stop()                      {}
+func (n nilRenderer) Kill()                      {}
+func (n nilRenderer) Write(_ string)             {}
+func (n nilRenderer) Repaint()                   {}
+func (n nilRenderer) ClearScreen()               {}
+func (n nilRenderer) AltScreen() bool            { return false }
+func (n nilRenderer) EnterAltScreen()            {}
+func (n nilRenderer) ExitAltScreen()             {}
+func (n nilRenderer) ShowCursor()                {}
+func (n nilRenderer) HideCursor()                {}
+func (n nilRenderer) EnableMouseCellMotion()     {}
+func (n nilRenderer) DisableMouseCellMotion()    {}
+func (n nilRenderer) EnableMouseAllMotion()      {}
+func (n nilRenderer) DisableMouseAllMotion()     {}
+func (n nilRenderer) EnableBracketedPaste()      {}
+func (n nilRenderer) DisableBracketedPaste()     {}
+func (n nilRenderer) EnableMouseSGRMode()        {}
+func (n nilRenderer) DisableMouseSGRMode()       {}
+func (n nilRenderer) BracketedPasteActive() bool { return false }
+func (n nilRenderer) EnableReportFocus()         {}
+func (n nilRenderer) DisableReportFocus()        {}
+func (n nilRenderer) ReportFocusActive() bool    { return false }
+func (n nilRenderer) EnableSynchronizedOutput()  {}
@@ -4,21 +4,27 @@ import "testing"
 
 func TestNilRenderer(t *testing.T) {
 	r := nilRenderer{}
-	r.start()
-	r.stop()
-	r.kill()
-	r.write("a")
-	r.repaint()
-	r.enterAltScreen()
-	if r.altScreen() {
+	r.Start()
+	r.Stop()
+	r.Kill()
+	r.Write("a")
+	r.Repaint()
+	r.EnterAltScreen()
+	if r.AltScreen() {
 		t.Errorf("altScreen should always return false")
 	}
-	r.exitAltScreen()
-	r.clearScreen()
-	r.showCursor()
-	r.hideCursor()
-	r.enableMouseCellMotion()
-	r.disableMouseCellMotion()
-	r.enableMouseAllMotion()
-	r.disableMouseAllMotion()
+	r.ExitAltScreen()
+	r.ClearScreen()
+	r.ShowCursor()
+	r.HideCursor()
+	r.EnableMouseCellMotion()
+	r.DisableMouseCellMotion()
+	r.EnableMouseAllMotion()
+	r.DisableMouseAllMotion()
+	r.EnableReportFocus()
+	if r.ReportFocusActive() {
+		t.Errorf("reportFocusActive should always return false")
+	}
+	r.DisableReportFocus()
+	r.EnableSynchronizedOutput()
 }
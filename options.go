@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"sync/atomic"
+	"time"
 
 	"github.com/muesli/termenv"
 )
@@ -73,6 +74,24 @@ func WithoutCatchPanics() ProgramOption {
 	}
 }
 
+// WithPanicMsgs makes panics in commands send a PanicMsg to Update instead of
+// ending the program. The PanicMsg carries the value the panic was called
+// with and the stack trace, so the program can report the error and carry
+// on:
+//
+//	case tea.PanicMsg:
+//	    m.err = fmt.Errorf("command panicked: %v", msg.Value)
+//	    return m, nil
+//
+// By default, a panic in a command restores the terminal and makes Run return
+// a PanicError. Panics in Update and View always end the program, and panic
+// catching can be disabled altogether with WithoutCatchPanics.
+func WithPanicMsgs() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withPanicMsgs
+	}
+}
+
 // WithoutSignals will ignore OS signals.
 // This is mainly useful for testing.
 func WithoutSignals() ProgramOption {
@@ -101,6 +120,174 @@ func WithAltScreen() ProgramOption {
 	}
 }
 
+// WithReportFocus starts the program with focus reporting enabled. When the
+// terminal gains or loses focus, a FocusMsg or a BlurMsg is sent to Update.
+// This can be used to pause animations or refresh stale data, for example.
+//
+// Not all terminals support focus reporting. Those that don't will simply
+// never send the events.
+//
+// To enable focus reporting once the program has already started running use
+// the EnableReportFocus command. Focus reporting will be automatically
+// disabled when the program exits.
+func WithReportFocus() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withReportFocus
+	}
+}
+
+// WithKeyboardEnhancements starts the program with the given kitty keyboard
+// protocol enhancements enabled, so terminals that support the protocol can
+// report keys that are otherwise indistinguishable, such as ctrl+i and tab,
+// along with all modifiers, key repeats and releases:
+//
+//	p := tea.NewProgram(Model{}, tea.WithKeyboardEnhancements(
+//	    tea.KeyboardDisambiguateEscapeCodes|tea.KeyboardReportEventTypes,
+//	))
+//
+// Terminals that don't support the protocol ignore the request and keep
+// reporting keys as usual. The enhancements apply to both the main screen
+// and the alt screen, are suspended while an Exec command runs and are
+// removed when the program exits.
+//
+// Note that with KeyboardReportEventTypes, key releases are reported as
+// KeyMsgs too. Check the EventType of a key to tell them apart from presses.
+func WithKeyboardEnhancements(flags KeyboardEnhancements) ProgramOption {
+	return func(p *Program) {
+		p.keyboardEnhancements = flags
+	}
+}
+
+// WithEscapeTimeout sets how long to wait for the rest of an escape sequence
+// after an escape character is received, before deciding what it is. When
+// nothing follows an escape character within the timeout, it's reported as the
+// escape key. This is similar to vim's ttimeoutlen.
+//
+// By default there's no timeout, and an escape character is reported as the
+// escape key as soon as it's the last byte available in the input. Over slow
+// connections, such as SSH, escape sequences can arrive in pieces, which are
+// then misreported as the escape key followed by other keys. A timeout of
+// 50 to 100 milliseconds usually avoids that:
+//
+//	p := tea.NewProgram(Model{}, tea.WithEscapeTimeout(50*time.Millisecond))
+//
+// Note that a longer timeout delays the escape key by as much.
+func WithEscapeTimeout(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.escTimeout = d
+	}
+}
+
+// WithMouseGestures enables the recognition of mouse gestures. In addition to
+// the MouseMsgs they're made of, gestures send MouseClickMsgs for single,
+// double and triple clicks, MouseDragStartMsg, MouseDragMoveMsg and
+// MouseDragEndMsg for drags, and MouseWheelMsg for accelerated wheel events.
+// Zero values in the given settings are replaced with defaults:
+//
+//	p := tea.NewProgram(Model{}, tea.WithMouseCellMotion(), tea.WithMouseGestures(tea.MouseGestures{
+//	    ClickInterval: 300 * time.Millisecond,
+//	}))
+//
+// The mouse still needs to be enabled for gestures to be recognized, for
+// instance with WithMouseCellMotion.
+func WithMouseGestures(g MouseGestures) ProgramOption {
+	return func(p *Program) {
+		p.gestures = newGestureRecognizer(g)
+	}
+}
+
+// WithMaxConcurrentCommands limits the number of commands that run at the same
+// time to n. The other commands wait in a queue until a running command
+// returns, so a Batch of thousands of commands doesn't start thousands of
+// goroutines at once. Use WithPriority to run some commands ahead of others.
+//
+// The commands of a Sequence or of All, including the batches they contain,
+// aren't queued, but they take one of the same n slots while they run, so
+// there are never more than n commands running at once. While an All waits
+// for its commands, the command that returned it gives its slot up.
+// Subscriptions don't count towards the limit.
+//
+// By default, or if n is less than 1, commands run as soon as they're issued.
+func WithMaxConcurrentCommands(n int) ProgramOption {
+	return func(p *Program) {
+		p.maxCmds = n
+	}
+}
+
+// WithMailbox gives the program a mailbox that holds up to size messages
+// waiting to be handled. Send and the program's input don't block until the
+// mailbox is full, which makes it possible to call Println and Printf from
+// Update, as long as there's room: like Send, they block while the mailbox is
+// full, which from Update is until the program is killed. Use TrySend to drop
+// messages when the mailbox is full instead of waiting.
+//
+// By default there's no mailbox, and sending a message blocks until the
+// program takes it.
+func WithMailbox(size int) ProgramOption {
+	return func(p *Program) {
+		if size > 0 {
+			p.mailboxSize = size
+		}
+	}
+}
+
+// WithCoalescing sets rules to coalesce consecutive messages waiting in the
+// mailbox, so that a flood of messages that supersede each other doesn't queue
+// up behind a slow Update. When a rule reports that a message is superseded by
+// the next one, only the next one is sent to Update:
+//
+//	p := tea.NewProgram(model{},
+//	    tea.WithMailbox(64),
+//	    tea.WithCoalescing(tea.CoalesceWindowSize, tea.CoalesceMouseMotion),
+//	)
+//
+// Messages are only coalesced with messages that are already waiting, so
+// coalescing works best with a mailbox, set with WithMailbox.
+func WithCoalescing(rules ...CoalesceFunc) ProgramOption {
+	return func(p *Program) {
+		p.coalescing = append(p.coalescing, rules...)
+	}
+}
+
+// WithMessagePriorities makes the program send messages to Update by priority
+// class: user input first, then messages about the terminal such as resizes,
+// then all other messages, such as the messages of commands. So, for example,
+// a key press doesn't wait behind hundreds of progress messages. Messages of
+// the same class are sent in the order they arrived.
+//
+// Messages are classified with the given function, or with DefaultMsgPriority
+// if it's nil. A custom classification can fall back to DefaultMsgPriority:
+//
+//	p := tea.NewProgram(model{}, tea.WithMessagePriorities(func(msg tea.Msg) tea.MsgPriority {
+//	    switch msg.(type) {
+//	    case cancelMsg:
+//	        return tea.PriorityInput
+//	    }
+//	    return tea.DefaultMsgPriority(msg)
+//	}))
+//
+// Note that messages of a lower class wait for as long as messages of a higher
+// class keep arriving.
+func WithMessagePriorities(classify func(Msg) MsgPriority) ProgramOption {
+	return func(p *Program) {
+		if classify == nil {
+			classify = DefaultMsgPriority
+		}
+		p.classify = classify
+	}
+}
+
+// WithShutdownTimeout makes Run wait up to the given duration for the commands
+// and subscriptions still running when the program quits or is killed.
+// Commands created with CmdContext are told to stop through their context, so
+// they usually return quickly. By default Run returns without waiting for
+// commands.
+func WithShutdownTimeout(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.shutdownTimeout = d
+	}
+}
+
 // WithoutBracketedPaste starts the program with bracketed paste disabled.
 func WithoutBracketedPaste() ProgramOption {
 	return func(p *Program) {
@@ -169,6 +356,19 @@ func WithoutRenderer() ProgramOption {
 	}
 }
 
+// WithRenderer sets a custom renderer for the Program. The Program will draw
+// its views and manage terminal state through it instead of the standard
+// renderer. Because of this, options that configure the standard renderer,
+// such as WithANSICompressor and WithFPS, have no effect.
+//
+// If the renderer also implements MessageHandler it will receive every
+// message the Program processes before it's passed to Update.
+func WithRenderer(r Renderer) ProgramOption {
+	return func(p *Program) {
+		p.renderer = r
+	}
+}
+
 // WithANSICompressor removes redundant ANSI sequences to produce potentially
 // smaller output, at the cost of some processing overhead.
 //
@@ -180,6 +380,44 @@ func WithANSICompressor() ProgramOption {
 	}
 }
 
+// WithCellRenderer makes the renderer diff frames cell by cell rather than line
+// by line. Each frame is parsed into styled cells, taking grapheme clusters and
+// wide characters into account, and only the runs of cells that changed are
+// written to the terminal. This can greatly reduce the amount of output for
+// views where only small parts change between frames, at the cost of some
+// processing overhead.
+//
+// This feature is provisional, and may be changed or removed in a future version
+// of this package.
+func WithCellRenderer() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withCellRenderer
+	}
+}
+
+// WithSynchronizedOutput forces synchronized output on. Each frame is wrapped
+// in synchronized update sequences (mode 2026), which keeps supporting
+// terminals from painting a partially written frame.
+//
+// By default Bubble Tea asks the terminal whether it supports synchronized
+// output and only uses it if it does. Terminals that don't support it will
+// ignore the sequences.
+func WithSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withSynchronizedOutput     // set
+		p.startupOptions &^= withoutSynchronizedOutput // clear
+	}
+}
+
+// WithoutSynchronizedOutput forces synchronized output off. Bubble Tea will
+// neither ask the terminal whether it supports synchronized output nor use it.
+func WithoutSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withoutSynchronizedOutput // set
+		p.startupOptions &^= withSynchronizedOutput   // clear
+	}
+}
+
 // WithFilter supplies an event filter that will be invoked before Bubble Tea
 // processes a tea.Msg. The event filter can return any tea.Msg which will then
 // get handled by Bubble Tea instead of the original event. If the event filter
@@ -215,6 +453,80 @@ func WithFilter(filter func(Model, Msg) Msg) ProgramOption {
 	}
 }
 
+// WithMiddleware wraps the program's update in the given middlewares. The
+// first middleware is the outermost one: it sees every message first and the
+// result of the update last. Middlewares can be set several times, in which
+// case the ones set later are inner to the ones set before.
+//
+//	p := tea.NewProgram(model{}, tea.WithMiddleware(logUpdates, quitOnCtrlC))
+//
+// Unlike the filter set with WithFilter, middlewares only see the messages
+// that are passed to Update, and not the messages Bubble Tea handles
+// internally, such as the ones of Batch and Sequence.
+func WithMiddleware(mws ...Middleware) ProgramOption {
+	return func(p *Program) {
+		p.middleware = append(p.middleware, mws...)
+	}
+}
+
+// WithTimeTravel enables a time-travel debugger, which records the last size
+// messages the program handled, along with the model Update returned for each
+// of them. Pressing the given key, such as "f12", opens the debugger, which
+// shows the view of a past state instead of the current one:
+//
+//   - left and right (or h and l), home and end pick the state to show.
+//   - x drops the message of the state shown from the history and replays the
+//     later messages from the state before it.
+//   - enter resumes the program from the state shown, forgetting the later
+//     ones.
+//   - esc, or the given key again, closes the debugger and shows the current
+//     state.
+//
+// Past views are rendered by calling View on past models, and messages are
+// replayed by calling Update without running the commands it returns, so
+// nothing outside the model is affected. While the debugger is open, it takes
+// the key and mouse messages, and other messages update the program as usual.
+//
+// Past states can only be shown if Update returns a new model rather than
+// changing the one it was given, so models with pointer receivers won't work
+// with the debugger.
+//
+//	p := tea.NewProgram(model{}, tea.WithTimeTravel("f12", 1000))
+func WithTimeTravel(key string, size int) ProgramOption {
+	return func(p *Program) {
+		p.travel = newTimeTravel(key, size)
+	}
+}
+
+// WithTrace writes a trace of the program to w, as JSON lines, to help debug
+// the order of messages and slow updates. There's a line for every message
+// the program takes, with its type and value, for every update, with how long
+// Update and View took, how long handing the view to the renderer took and
+// whether a command was returned, for the start and end of every command,
+// with how long it ran, and for every frame the standard renderer writes to
+// the terminal, with its size and how long it took:
+//
+//	{"time":"…","event":"msg","type":"tea.KeyMsg","value":"enter"}
+//	{"time":"…","event":"update","type":"tea.KeyMsg","update_us":12,"cmd":true,"view_us":85,"write_us":6}
+//	{"time":"…","event":"cmd_start","id":1}
+//	{"time":"…","event":"flush","bytes":412,"duration_us":140}
+//	{"time":"…","event":"cmd_end","type":"main.resultMsg","id":1,"duration_us":5012}
+//
+// For example, to trace to a file:
+//
+//	f, err := os.Create("trace.jsonl")
+//	if err != nil {
+//	    // ...
+//	}
+//	defer f.Close()
+//
+//	p := tea.NewProgram(model{}, tea.WithTrace(f))
+func WithTrace(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.tracer = newTracer(w)
+	}
+}
+
 // WithFPS sets a custom maximum FPS at which the renderer should run. If
 // less than 1, the default value of 60 will be used. If over 120, the FPS
 // will be capped at 120.
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -36,6 +37,89 @@ func TestOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("custom renderer", func(t *testing.T) {
+		r := &nilRenderer{}
+		p := NewProgram(nil, WithRenderer(r))
+		if p.renderer != r {
+			t.Errorf("expected renderer to be the custom renderer, got %v", p.renderer)
+		}
+	})
+
+	t.Run("keyboard enhancements", func(t *testing.T) {
+		flags := KeyboardDisambiguateEscapeCodes | KeyboardReportEventTypes
+		p := NewProgram(nil, WithKeyboardEnhancements(flags))
+		if p.keyboardEnhancements != flags {
+			t.Errorf("expected keyboard enhancements %d, got %d", flags, p.keyboardEnhancements)
+		}
+	})
+
+	t.Run("mouse gestures", func(t *testing.T) {
+		p := NewProgram(nil, WithMouseGestures(MouseGestures{DragThreshold: 3}))
+		if p.gestures == nil {
+			t.Fatal("expected gestures to be enabled")
+		}
+		if p.gestures.DragThreshold != 3 || p.gestures.ClickInterval != defaultClickInterval {
+			t.Errorf("unexpected gesture settings: %+v", p.gestures.MouseGestures)
+		}
+	})
+
+	t.Run("max concurrent commands", func(t *testing.T) {
+		p := NewProgram(nil, WithMaxConcurrentCommands(4))
+		if p.maxCmds != 4 {
+			t.Errorf("expected max concurrent commands to be 4, got %d", p.maxCmds)
+		}
+	})
+
+	t.Run("message priorities", func(t *testing.T) {
+		p := NewProgram(nil, WithMessagePriorities(nil))
+		if p.classify == nil {
+			t.Fatal("expected message priorities to be enabled")
+		}
+		if c := p.classify(KeyMsg{}); c != PriorityInput {
+			t.Errorf("expected keys to be classified as input, got %d", c)
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		mw := func(next UpdateFunc) UpdateFunc { return next }
+		p := NewProgram(nil, WithMiddleware(mw), WithMiddleware(mw, mw))
+		if len(p.middleware) != 3 {
+			t.Errorf("expected 3 middlewares, got %d", len(p.middleware))
+		}
+	})
+
+	t.Run("mailbox", func(t *testing.T) {
+		p := NewProgram(nil, WithMailbox(16), WithCoalescing(CoalesceWindowSize))
+		if cap(p.msgs) != 16 {
+			t.Errorf("expected a mailbox of 16 messages, got %d", cap(p.msgs))
+		}
+		if len(p.coalescing) != 1 {
+			t.Errorf("expected 1 coalescing rule, got %d", len(p.coalescing))
+		}
+	})
+
+	t.Run("time travel", func(t *testing.T) {
+		p := NewProgram(nil, WithTimeTravel("f12", 100))
+		if p.travel == nil || p.travel.key != "f12" || p.travel.size != 100 {
+			t.Errorf("expected a time-travel debugger, got %+v", p.travel)
+		}
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := NewProgram(nil, WithTrace(&buf))
+		if p.tracer == nil {
+			t.Error("expected a tracer")
+		}
+	})
+
+	t.Run("shutdown timeout", func(t *testing.T) {
+		p := NewProgram(nil, WithShutdownTimeout(time.Second))
+		if p.shutdownTimeout != time.Second {
+			t.Errorf("expected shutdown timeout to be %v, got %v", time.Second, p.shutdownTimeout)
+		}
+	})
+
 	t.Run("without signals", func(t *testing.T) {
 		p := NewProgram(nil, WithoutSignals())
 		if atomic.LoadUint32(&p.ignoreSignals) == 0 {
@@ -81,6 +165,10 @@ func TestOptions(t *testing.T) {
 			exercise(t, WithAltScreen(), withAltScreen)
 		})
 
+		t.Run("report focus", func(t *testing.T) {
+			exercise(t, WithReportFocus(), withReportFocus)
+		})
+
 		t.Run("bracketed paste disabled", func(t *testing.T) {
 			exercise(t, WithoutBracketedPaste(), withoutBracketedPaste)
 		})
@@ -89,14 +177,42 @@ func TestOptions(t *testing.T) {
 			exercise(t, WithANSICompressor(), withANSICompressor)
 		})
 
+		t.Run("cell renderer", func(t *testing.T) {
+			exercise(t, WithCellRenderer(), withCellRenderer)
+		})
+
 		t.Run("without catch panics", func(t *testing.T) {
 			exercise(t, WithoutCatchPanics(), withoutCatchPanics)
 		})
 
+		t.Run("panic msgs", func(t *testing.T) {
+			exercise(t, WithPanicMsgs(), withPanicMsgs)
+		})
+
 		t.Run("without signal handler", func(t *testing.T) {
 			exercise(t, WithoutSignalHandler(), withoutSignalHandler)
 		})
 
+		t.Run("synchronized output", func(t *testing.T) {
+			p := NewProgram(nil, WithoutSynchronizedOutput(), WithSynchronizedOutput())
+			if !p.startupOptions.has(withSynchronizedOutput) {
+				t.Errorf("expected startup options have %v, got %v", withSynchronizedOutput, p.startupOptions)
+			}
+			if p.startupOptions.has(withoutSynchronizedOutput) {
+				t.Errorf("expected startup options not have %v, got %v", withoutSynchronizedOutput, p.startupOptions)
+			}
+		})
+
+		t.Run("without synchronized output", func(t *testing.T) {
+			p := NewProgram(nil, WithSynchronizedOutput(), WithoutSynchronizedOutput())
+			if !p.startupOptions.has(withoutSynchronizedOutput) {
+				t.Errorf("expected startup options have %v, got %v", withoutSynchronizedOutput, p.startupOptions)
+			}
+			if p.startupOptions.has(withSynchronizedOutput) {
+				t.Errorf("expected startup options not have %v, got %v", withSynchronizedOutput, p.startupOptions)
+			}
+		})
+
 		t.Run("mouse cell motion", func(t *testing.T) {
 			p := NewProgram(nil, WithMouseAllMotion(), WithMouseCellMotion())
 			if !p.startupOptions.has(withMouseCellMotion) {
@@ -0,0 +1,181 @@
+package tea
+
+import (
+	"container/heap"
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// cmdSlot is one of the slots for concurrent commands, taken by a running
+// command. It's kept in the context the command runs with, so that commands
+// that wait for other commands, such as All, can give it up while they wait.
+type cmdSlot struct {
+	once  sync.Once
+	slots chan struct{}
+}
+
+// release gives the slot up. Only the first call has an effect.
+func (s *cmdSlot) release() {
+	s.once.Do(func() { <-s.slots })
+}
+
+type cmdSlotKey struct{}
+
+// priorityCmdCode is the code of the commands created with WithPriority.
+var priorityCmdCode = reflect.ValueOf(priorityCmdMsg{}.msg).Pointer()
+
+// unwrapPriority returns the command given a priority with WithPriority and
+// that priority, if cmd was created with WithPriority, or cmd and the given
+// priority otherwise. These commands are recognized by their code, so that
+// their priority is known before they're queued without running commands.
+func unwrapPriority(cmd Cmd, priority int) (Cmd, int) {
+	for cmd != nil && reflect.ValueOf(cmd).Pointer() == priorityCmdCode {
+		m := cmd().(priorityCmdMsg)
+		cmd, priority = m.cmd, m.priority
+	}
+	return cmd, priority
+}
+
+// releaseCmdSlot gives up the slot of the command running with ctx, if it has
+// one.
+func releaseCmdSlot(ctx context.Context) {
+	if s, ok := ctx.Value(cmdSlotKey{}).(*cmdSlot); ok {
+		s.release()
+	}
+}
+
+// queuedCmd is a command waiting for a worker of the command pool.
+type queuedCmd struct {
+	cmd      Cmd
+	priority int
+	seq      uint64 // keeps commands of the same priority in order
+}
+
+// cmdQueue is a priority queue of commands. Commands with a higher priority
+// come first, and commands of the same priority come in the order they were
+// queued.
+type cmdQueue []queuedCmd
+
+func (q cmdQueue) Len() int { return len(q) }
+
+func (q cmdQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q cmdQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *cmdQueue) Push(x interface{}) { *q = append(*q, x.(queuedCmd)) }
+
+func (q *cmdQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = queuedCmd{}
+	*q = old[:n-1]
+	return item
+}
+
+// handleCommandsPool is like handleCommands, but runs at most maxCmds commands
+// at a time. The other commands wait in a queue, ordered by priority, until one
+// of the slots for concurrent commands, which sequences take too, is free.
+func (p *Program) handleCommandsPool(cmds chan Cmd) chan struct{} {
+	ch := make(chan struct{})
+
+	go func() {
+		defer close(ch)
+
+		var (
+			queue cmdQueue
+			seq   uint64
+		)
+		push := func(cmd Cmd, priority int) {
+			cmd, priority = unwrapPriority(cmd, priority)
+			if cmd == nil {
+				return
+			}
+			seq++
+			heap.Push(&queue, queuedCmd{cmd: cmd, priority: priority, seq: seq})
+		}
+
+		// Workers report the commands they queue when they're done.
+		done := make(chan []queuedCmd)
+
+		for {
+			// Wait for a slot only if a command is waiting for one.
+			var slots chan struct{}
+			if queue.Len() > 0 {
+				slots = p.cmdSlots
+			}
+
+			select {
+			case <-p.ctx.Done():
+				return
+
+			case cmd := <-cmds:
+				push(cmd, 0)
+
+			case next := <-done:
+				for _, c := range next {
+					push(c.cmd, c.priority)
+				}
+
+			case slots <- struct{}{}:
+				item := heap.Pop(&queue).(queuedCmd)
+				slot := &cmdSlot{slots: p.cmdSlots}
+				ctx := context.WithValue(p.ctx, cmdSlotKey{}, slot)
+				p.runningCmds.Add(1)
+				go func() {
+					defer p.runningCmds.Done()
+					var next []queuedCmd
+					defer func() {
+						if len(next) == 0 {
+							return
+						}
+						select {
+						case done <- next:
+						case <-p.ctx.Done():
+						}
+					}()
+					defer slot.release()
+					defer p.recoverPanic()
+					next = p.runQueuedCmd(ctx, item)
+				}()
+			}
+		}
+	}()
+
+	return ch
+}
+
+// runQueuedCmd runs a command of the command pool with a context that holds
+// its slot, and sends its message to the program. The commands of a batch
+// returned by a command with a priority are returned to be queued with that
+// priority, so that they inherit it, and so is a command with a priority
+// returned by a command, rather than created with WithPriority.
+func (p *Program) runQueuedCmd(ctx context.Context, item queuedCmd) []queuedCmd {
+	start := time.Now()
+	msg := item.cmd()
+	if m, ok := msg.(priorityCmdMsg); ok {
+		// Only the command given the priority is traced, once it runs.
+		return []queuedCmd{{cmd: m.cmd, priority: m.priority}}
+	}
+	done := p.traceCmdSince(start)
+	msg = p.runCmdMsg(ctx, msg)
+	done(msg)
+
+	if batch, ok := msg.(BatchMsg); ok && item.priority != 0 {
+		next := make([]queuedCmd, 0, len(batch))
+		for _, cmd := range batch {
+			next = append(next, queuedCmd{cmd: cmd, priority: item.priority})
+		}
+		return next
+	}
+
+	p.Send(msg)
+	return nil
+}
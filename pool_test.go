@@ -0,0 +1,127 @@
+package tea
+
+import (
+	"bytes"
+	"container/heap"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCmdQueue(t *testing.T) {
+	var q cmdQueue
+	for i, priority := range []int{0, -1, 2, 0, 2} {
+		heap.Push(&q, queuedCmd{priority: priority, seq: uint64(i)})
+	}
+
+	var order []uint64
+	for q.Len() > 0 {
+		order = append(order, heap.Pop(&q).(queuedCmd).seq)
+	}
+	if expected := []uint64{2, 4, 0, 3, 1}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected commands in order %v, got %v", expected, order)
+	}
+}
+
+func TestTeaMaxConcurrentCommands(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	const n = 3
+	var running, maxRunning int32
+	cmd := func() Msg {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return incrementMsg{}
+	}
+	cmds := make([]Cmd, 50)
+	for i := range cmds {
+		cmds[i] = cmd
+	}
+
+	// The commands of sequences share the limit with the others.
+	m := &initCmdModel{init: Batch(Batch(cmds[:25]...), Sequence(Batch(cmds[25:]...)))}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMaxConcurrentCommands(n))
+	go func() {
+		for {
+			time.Sleep(time.Millisecond)
+			i := m.counter.Load()
+			if i != nil && i.(int) >= len(cmds) {
+				p.Quit()
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning > n {
+		t.Errorf("expected at most %d concurrent commands, got %d", n, maxRunning)
+	}
+}
+
+func TestTeaCommandPriority(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	var (
+		mtx   sync.Mutex
+		order []string
+	)
+	record := func(name string) Cmd {
+		return func() Msg {
+			mtx.Lock()
+			defer mtx.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	started, gate := make(chan struct{}), make(chan struct{})
+	m := &initCmdModel{init: func() Msg {
+		close(started)
+		<-gate
+		return nil
+	}}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithMaxConcurrentCommands(1))
+	go func() {
+		// The first command holds the only worker while the others are
+		// queued.
+		<-started
+		p.Send(BatchMsg{
+			WithPriority(Batch(record("low 1"), record("low 2")), -1),
+			record("default"),
+			WithPriority(record("high"), 1),
+		})
+		p.Send(incrementMsg{})
+		close(gate)
+
+		for {
+			time.Sleep(time.Millisecond)
+			mtx.Lock()
+			n := len(order)
+			mtx.Unlock()
+			if n == 4 {
+				p.Quit()
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"high", "default", "low 1", "low 2"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected commands to run in order %v, got %v", expected, order)
+	}
+}
@@ -1,72 +1,139 @@
 package tea
 
-// renderer is the interface for Bubble Tea renderers.
-type renderer interface {
+// Renderer is the interface for Bubble Tea renderers. A Program draws its
+// views and manages terminal state, such as the alternate screen buffer and
+// the mouse, through its renderer.
+//
+// By default a Program uses a framerate-based renderer that paints to the
+// Program's output. A custom renderer can be set with the WithRenderer
+// ProgramOption, which is useful for things like recording, mirroring or
+// accessible renderers.
+//
+// Renderers that need to react to the messages a Program processes, such as
+// WindowSizeMsg, can also implement MessageHandler.
+type Renderer interface {
 	// Start the renderer.
-	start()
+	Start()
 
 	// Stop the renderer, but render the final frame in the buffer, if any.
-	stop()
+	Stop()
 
 	// Stop the renderer without doing any final rendering.
-	kill()
+	Kill()
 
 	// Write a frame to the renderer. The renderer can write this data to
 	// output at its discretion.
-	write(string)
+	Write(string)
 
 	// Request a full re-render. Note that this will not trigger a render
 	// immediately. Rather, this method causes the next render to be a full
 	// repaint. Because of this, it's safe to call this method multiple times
 	// in succession.
-	repaint()
+	Repaint()
 
 	// Clears the terminal.
-	clearScreen()
+	ClearScreen()
 
 	// Whether or not the alternate screen buffer is enabled.
-	altScreen() bool
+	AltScreen() bool
 	// Enable the alternate screen buffer.
-	enterAltScreen()
+	EnterAltScreen()
 	// Disable the alternate screen buffer.
-	exitAltScreen()
+	ExitAltScreen()
 
 	// Show the cursor.
-	showCursor()
+	ShowCursor()
 	// Hide the cursor.
-	hideCursor()
+	HideCursor()
 
-	// enableMouseCellMotion enables mouse click, release, wheel and motion
+	// EnableMouseCellMotion enables mouse click, release, wheel and motion
 	// events if a mouse button is pressed (i.e., drag events).
-	enableMouseCellMotion()
+	EnableMouseCellMotion()
 
-	// disableMouseCellMotion disables Mouse Cell Motion tracking.
-	disableMouseCellMotion()
+	// DisableMouseCellMotion disables Mouse Cell Motion tracking.
+	DisableMouseCellMotion()
 
-	// enableMouseAllMotion enables mouse click, release, wheel and motion
+	// EnableMouseAllMotion enables mouse click, release, wheel and motion
 	// events, regardless of whether a mouse button is pressed. Many modern
 	// terminals support this, but not all.
-	enableMouseAllMotion()
+	EnableMouseAllMotion()
 
-	// disableMouseAllMotion disables All Motion mouse tracking.
-	disableMouseAllMotion()
+	// DisableMouseAllMotion disables All Motion mouse tracking.
+	DisableMouseAllMotion()
 
-	// enableMouseSGRMode enables mouse extended mode (SGR).
-	enableMouseSGRMode()
+	// EnableMouseSGRMode enables mouse extended mode (SGR).
+	EnableMouseSGRMode()
 
-	// disableMouseSGRMode disables mouse extended mode (SGR).
-	disableMouseSGRMode()
+	// DisableMouseSGRMode disables mouse extended mode (SGR).
+	DisableMouseSGRMode()
 
-	// enableBracketedPaste enables bracketed paste, where characters
+	// EnableBracketedPaste enables bracketed paste, where characters
 	// inside the input are not interpreted when pasted as a whole.
-	enableBracketedPaste()
+	EnableBracketedPaste()
 
-	// disableBracketedPaste disables bracketed paste.
-	disableBracketedPaste()
+	// DisableBracketedPaste disables bracketed paste.
+	DisableBracketedPaste()
 
-	// bracketedPasteActive reports whether bracketed paste mode is
+	// BracketedPasteActive reports whether bracketed paste mode is
 	// currently enabled.
-	bracketedPasteActive() bool
+	BracketedPasteActive() bool
+
+	// EnableReportFocus enables reporting when the terminal gains and loses
+	// focus.
+	EnableReportFocus()
+
+	// DisableReportFocus disables focus reporting.
+	DisableReportFocus()
+
+	// ReportFocusActive reports whether focus reporting is currently
+	// enabled.
+	ReportFocusActive() bool
+
+	// EnableSynchronizedOutput wraps each frame in synchronized update
+	// sequences so the terminal paints it in one go.
+	EnableSynchronizedOutput()
+}
+
+// MessageHandler is an optional interface for renderers. If a Program's
+// renderer implements it, HandleMessage is called with every message the
+// Program processes, right before it's passed to the model's Update function.
+type MessageHandler interface {
+	HandleMessage(Msg)
+}
+
+// CursorRenderer is an optional interface for renderers that can place the
+// terminal cursor. If a Program's renderer implements it, views of models
+// that implement CursorModel are written with WriteWithCursor instead of
+// Write.
+type CursorRenderer interface {
+	// WriteWithCursor writes a frame to the renderer along with the position
+	// the cursor should be moved to once the frame is painted, relative to
+	// the top-left corner of the frame. Negative coordinates mean that no
+	// position was requested.
+	WriteWithCursor(view string, x, y int)
+}
+
+// ZoneRenderer is an optional interface for renderers that keep track of the
+// zones marked with Zone in the frames they paint. If a Program's renderer
+// implements it, the Program reports the zones under the mouse in the MouseMsgs
+// it receives.
+//
+// The frames written to a ZoneRenderer keep the zone markers, which it must
+// strip before painting, with StripZones or the like, while the Program strips
+// them from the frames of other renderers.
+type ZoneRenderer interface {
+	// ZonesAt returns the IDs of the zones at the given position on the
+	// screen, innermost first.
+	ZonesAt(x, y int) []string
+}
+
+// LinePrinter is an optional interface for renderers that can print lines
+// above the Program, such as the lines printed with Println and Printf. If a
+// Program's renderer doesn't implement it, printed lines are dropped.
+type LinePrinter interface {
+	// PrintLine prints a message above the Program, on lines of its own. The
+	// message may contain newlines.
+	PrintLine(message string)
 }
 
 // repaintMsg forces a full repaint.
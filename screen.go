@@ -144,13 +144,39 @@ func DisableBracketedPaste() Msg {
 // disableBracketedPasteMsg with DisableBracketedPaste.
 type disableBracketedPasteMsg struct{}
 
+// EnableReportFocus is a special command that tells the Bubble Tea program to
+// report when the terminal gains and loses focus, which is delivered to Update
+// as a FocusMsg and a BlurMsg respectively.
+//
+// Note that focus reporting will be automatically disabled when the program
+// quits.
+func EnableReportFocus() Msg {
+	return enableReportFocusMsg{}
+}
+
+// enableReportFocusMsg is an internal message that signals that focus
+// reporting should be enabled. You can send an enableReportFocusMsg with
+// EnableReportFocus.
+type enableReportFocusMsg struct{}
+
+// DisableReportFocus is a special command that tells the Bubble Tea program
+// to stop reporting when the terminal gains and loses focus.
+func DisableReportFocus() Msg {
+	return disableReportFocusMsg{}
+}
+
+// disableReportFocusMsg is an internal message that signals that focus
+// reporting should be disabled. You can send a disableReportFocusMsg with
+// DisableReportFocus.
+type disableReportFocusMsg struct{}
+
 // EnterAltScreen enters the alternate screen buffer, which consumes the entire
 // terminal window. ExitAltScreen will return the terminal to its former state.
 //
 // Deprecated: Use the WithAltScreen ProgramOption instead.
 func (p *Program) EnterAltScreen() {
 	if p.renderer != nil {
-		p.renderer.enterAltScreen()
+		p.renderer.EnterAltScreen()
 	}
 }
 
@@ -159,7 +185,7 @@ func (p *Program) EnterAltScreen() {
 // Deprecated: The altscreen will exited automatically when the program exits.
 func (p *Program) ExitAltScreen() {
 	if p.renderer != nil {
-		p.renderer.exitAltScreen()
+		p.renderer.ExitAltScreen()
 	}
 }
 
@@ -168,7 +194,7 @@ func (p *Program) ExitAltScreen() {
 //
 // Deprecated: Use the WithMouseCellMotion ProgramOption instead.
 func (p *Program) EnableMouseCellMotion() {
-	p.renderer.enableMouseCellMotion()
+	p.renderer.EnableMouseCellMotion()
 }
 
 // DisableMouseCellMotion disables Mouse Cell Motion tracking. This will be
@@ -176,7 +202,7 @@ func (p *Program) EnableMouseCellMotion() {
 //
 // Deprecated: The mouse will automatically be disabled when the program exits.
 func (p *Program) DisableMouseCellMotion() {
-	p.renderer.disableMouseCellMotion()
+	p.renderer.DisableMouseCellMotion()
 }
 
 // EnableMouseAllMotion enables mouse click, release, wheel and motion events,
@@ -185,7 +211,7 @@ func (p *Program) DisableMouseCellMotion() {
 //
 // Deprecated: Use the WithMouseAllMotion ProgramOption instead.
 func (p *Program) EnableMouseAllMotion() {
-	p.renderer.enableMouseAllMotion()
+	p.renderer.EnableMouseAllMotion()
 }
 
 // DisableMouseAllMotion disables All Motion mouse tracking. This will be
@@ -193,7 +219,7 @@ func (p *Program) EnableMouseAllMotion() {
 //
 // Deprecated: The mouse will automatically be disabled when the program exits.
 func (p *Program) DisableMouseAllMotion() {
-	p.renderer.disableMouseAllMotion()
+	p.renderer.DisableMouseAllMotion()
 }
 
 // SetWindowTitle sets the terminal window title.
@@ -2,7 +2,12 @@ package tea
 
 import (
 	"bytes"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/muesli/termenv"
 )
 
 func TestClearMsg(t *testing.T) {
@@ -51,6 +56,16 @@ func TestClearMsg(t *testing.T) {
 			cmds:     []Cmd{HideCursor, ShowCursor},
 			expected: "\x1b[?25l\x1b[?2004h\x1b[?25l\x1b[?25hsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
 		},
+		{
+			name:     "report_focus",
+			cmds:     []Cmd{EnableReportFocus},
+			expected: "\x1b[?25l\x1b[?2004h\x1b[?1004hsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?1004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "report_focus_disable",
+			cmds:     []Cmd{EnableReportFocus, DisableReportFocus},
+			expected: "\x1b[?25l\x1b[?2004h\x1b[?1004h\x1b[?1004lsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
 		{
 			name:     "bp_stop_start",
 			cmds:     []Cmd{DisableBracketedPaste, EnableBracketedPaste},
@@ -79,3 +94,173 @@ func TestClearMsg(t *testing.T) {
 		})
 	}
 }
+
+type cursorTestModel struct {
+	testModel
+	x, y int
+}
+
+func (m *cursorTestModel) View() string {
+	return "success\n"
+}
+
+func (m *cursorTestModel) CursorPosition() (int, int) {
+	return m.x, m.y
+}
+
+func TestCursorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ProgramOption
+		x, y     int
+		expected string
+	}{
+		{
+			name:     "inline",
+			x:        3,
+			expected: "\x1b[?25l\x1b[?2004hsuccess\r\n\x1b[0D\x1b[1A\x1b[3C\x1b[1B\r\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "altscreen",
+			opts:     []ProgramOption{WithAltScreen()},
+			x:        3,
+			expected: "\x1b[?25l\x1b[?1049h\x1b[2J\x1b[1;1H\x1b[1;1H\x1b[?25l\x1b[?2004hsuccess\r\n\x1b[2;0H\x1b[1;4H\x1b[2;0H\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?1049l\x1b[?25h",
+		},
+		{
+			name:     "no position",
+			x:        -1,
+			y:        -1,
+			expected: "\x1b[?25l\x1b[?2004hsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var in bytes.Buffer
+
+			m := &cursorTestModel{x: test.x, y: test.y}
+			opts := append([]ProgramOption{WithInput(&in), WithOutput(&buf)}, test.opts...)
+			p := NewProgram(m, opts...)
+			go p.Send(Quit())
+
+			if _, err := p.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != test.expected {
+				t.Errorf("expected embedded sequence:\n%q\ngot:\n%q", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestKeyboardEnhancements(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testExecModel{cmd: "true"}
+	flags := KeyboardDisambiguateEscapeCodes | KeyboardReportEventTypes
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithKeyboardEnhancements(flags))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flags are pushed on start and after exec, and popped before exec
+	// and on exit.
+	out := buf.String()
+	if !strings.HasPrefix(out, "\x1b[?25l\x1b[>3u") {
+		t.Errorf("expected output to start by pushing the flags, got %q", out)
+	}
+	if n := strings.Count(out, "\x1b[>3u"); n != 2 {
+		t.Errorf("expected flags to be pushed 2 times, got %d in %q", n, out)
+	}
+	if n := strings.Count(out, "\x1b[<u"); n != 2 {
+		t.Errorf("expected flags to be popped 2 times, got %d in %q", n, out)
+	}
+	if strings.LastIndex(out, "\x1b[<u") < strings.LastIndex(out, "\x1b[>3u") {
+		t.Errorf("expected flags to be popped on exit, got %q", out)
+	}
+}
+
+func TestKeyboardEnhancementsAltScreen(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testExecModel{cmd: "true"}
+	flags := KeyboardDisambiguateEscapeCodes | KeyboardReportEventTypes
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithAltScreen(), WithKeyboardEnhancements(flags))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Terminals keep separate stacks of flags for the main and alt screens,
+	// so the flags are pushed on each screen, and popped from each before
+	// leaving it. Exec leaves the alt screen and comes back to it.
+	const (
+		push  = "\x1b[>3u"
+		pop   = "\x1b[<u"
+		enter = "\x1b[?1049h"
+		exit  = "\x1b[?1049l"
+	)
+	var order []string
+	out := buf.String()
+	for len(out) > 0 {
+		next, i := "", len(out)
+		for _, seq := range []string{push, pop, enter, exit} {
+			if j := strings.Index(out, seq); j >= 0 && j < i {
+				next, i = seq, j
+			}
+		}
+		if next == "" {
+			break
+		}
+		order = append(order, next)
+		out = out[i+len(next):]
+	}
+
+	once := []string{push, enter, push, pop, exit, pop}
+	expected := append(append([]string(nil), once...), once...)
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected sequences in order %q, got %q", expected, order)
+	}
+}
+
+func TestRendererRepaint(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, true, defaultFPS).(*standardRenderer)
+	r.Write("frame")
+	r.flush()
+
+	// An unchanged frame isn't painted again, unless a repaint is requested.
+	n := buf.Len()
+	r.Write("frame")
+	r.flush()
+	if buf.Len() != n {
+		t.Fatalf("expected the unchanged frame not to be painted, got %q", buf.String()[n:])
+	}
+	r.Repaint()
+	r.Write("frame")
+	r.flush()
+	if !strings.Contains(buf.String()[n:], "frame") {
+		t.Errorf("expected the frame to be painted again after a repaint, got %q", buf.String()[n:])
+	}
+
+	// Repaint is safe to call while the renderer flushes.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.Repaint()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.Write("frame")
+			r.flush()
+		}
+	}()
+	wg.Wait()
+}
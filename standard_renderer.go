@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
@@ -39,15 +40,54 @@ type standardRenderer struct {
 	useANSICompressor  bool
 	once               sync.Once
 
+	// whether or not to diff frames cell by cell rather than line by line,
+	// along with the cells of the last frame we painted
+	useCellRenderer bool
+	lastCells       []cellLine
+
 	// cursor visibility state
 	cursorHidden bool
 
+	// the kitty keyboard enhancement flags pushed by the program, if any,
+	// which are pushed again for the alt screen, since terminals keep a
+	// separate stack of flags for it
+	keyboardEnhancements KeyboardEnhancements
+
+	// called with the size of each frame written to the terminal and how
+	// long it took, when the program is traced
+	traceFlush func(size int, d time.Duration)
+
+	// called with a panic recovered while flushing, after which the renderer
+	// stops flushing
+	handlePanic func(r interface{}, stack []byte)
+	panicked    bool
+
+	// the cursor position requested with the frame in the buffer and the
+	// position we last moved the cursor to, relative to the top of the frame
+	cursor       cursorPosition
+	placedCursor cursorPosition
+
+	// number of lines dropped from the top of the last frame because it
+	// didn't fit the screen
+	linesTrimmed int
+
+	// the mouse zones of the frame in the buffer and of the last frame we
+	// painted
+	bufZones []zone
+	zones    []zone
+
 	// essentially whether or not we're using the full size of the terminal
 	altScreenActive bool
 
 	// whether or not we're currently using bracketed paste
 	bpActive bool
 
+	// whether or not we're currently reporting focus events
+	reportFocus bool
+
+	// whether or not frames are wrapped in synchronized updates
+	syncOutput bool
+
 	// renderer dimensions; usually the size of the window
 	width  int
 	height int
@@ -56,9 +96,19 @@ type standardRenderer struct {
 	ignoreLines map[int]struct{}
 }
 
+// cursorPosition is a position of the cursor relative to the top-left corner
+// of a frame.
+type cursorPosition struct {
+	x, y int
+}
+
+// noCursor means that no cursor position was requested, in which case the
+// cursor is left at the start of the last line.
+var noCursor = cursorPosition{-1, -1}
+
 // newRenderer creates a new renderer. Normally you'll want to initialize it
 // with os.Stdout as the first argument.
-func newRenderer(out *termenv.Output, useANSICompressor bool, fps int) renderer {
+func newRenderer(out *termenv.Output, useANSICompressor, useCellRenderer bool, fps int) Renderer {
 	if fps < 1 {
 		fps = defaultFPS
 	} else if fps > maxFPS {
@@ -70,7 +120,10 @@ func newRenderer(out *termenv.Output, useANSICompressor bool, fps int) renderer
 		done:               make(chan struct{}),
 		framerate:          time.Second / time.Duration(fps),
 		useANSICompressor:  useANSICompressor,
+		useCellRenderer:    useCellRenderer,
 		queuedMessageLines: []string{},
+		cursor:             noCursor,
+		placedCursor:       noCursor,
 	}
 	if r.useANSICompressor {
 		r.out = termenv.NewOutput(&compressor.Writer{Forward: out})
@@ -78,8 +131,8 @@ func newRenderer(out *termenv.Output, useANSICompressor bool, fps int) renderer
 	return r
 }
 
-// start starts the renderer.
-func (r *standardRenderer) start() {
+// Start starts the renderer.
+func (r *standardRenderer) Start() {
 	if r.ticker == nil {
 		r.ticker = time.NewTicker(r.framerate)
 	} else {
@@ -95,8 +148,8 @@ func (r *standardRenderer) start() {
 	go r.listen()
 }
 
-// stop permanently halts the renderer, rendering the final frame.
-func (r *standardRenderer) stop() {
+// Stop permanently halts the renderer, rendering the final frame.
+func (r *standardRenderer) Stop() {
 	// Stop the renderer before acquiring the mutex to avoid a deadlock.
 	r.once.Do(func() {
 		r.done <- struct{}{}
@@ -108,6 +161,7 @@ func (r *standardRenderer) stop() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
+	r.unplaceCursor(r.out)
 	r.out.ClearLine()
 
 	if r.useANSICompressor {
@@ -117,8 +171,8 @@ func (r *standardRenderer) stop() {
 	}
 }
 
-// kill halts the renderer. The final frame will not be rendered.
-func (r *standardRenderer) kill() {
+// Kill halts the renderer. The final frame will not be rendered.
+func (r *standardRenderer) Kill() {
 	// Stop the renderer before acquiring the mutex to avoid a deadlock.
 	r.once.Do(func() {
 		r.done <- struct{}{}
@@ -127,11 +181,30 @@ func (r *standardRenderer) kill() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
+	r.unplaceCursor(r.out)
 	r.out.ClearLine()
 }
 
 // listen waits for ticks on the ticker, or a signal to stop the renderer.
 func (r *standardRenderer) listen() {
+	if r.handlePanic != nil {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			r.mtx.Lock()
+			r.panicked = true
+			r.mtx.Unlock()
+			r.handlePanic(v, debug.Stack())
+
+			// Keep waiting for the signal to stop, so that stopping the
+			// renderer doesn't block.
+			<-r.done
+			r.ticker.Stop()
+		}()
+	}
+
 	for {
 		select {
 		case <-r.done:
@@ -149,29 +222,147 @@ func (r *standardRenderer) flush() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	if r.buf.Len() == 0 || r.buf.String() == r.lastRender {
+	if r.buf.Len() == 0 || r.panicked {
+		// Nothing to do, or the last flush panicked and the state of the
+		// screen is unknown
+		return
+	}
+	frameChanged := r.buf.String() != r.lastRender
+	if !frameChanged && r.cursor == r.placedCursor {
 		// Nothing to do
 		return
 	}
+	start := time.Now()
 
 	// Output buffer
 	buf := &bytes.Buffer{}
 	out := termenv.NewOutput(buf)
 
+	// Have the terminal hold off painting until the frame is complete to
+	// avoid tearing.
+	if r.syncOutput {
+		_, _ = out.WriteString(beginSynchronizedUpdate)
+	}
+
+	// Return the cursor to where the rendering routine expects it to be.
+	r.unplaceCursor(out)
+
+	if frameChanged {
+		r.paint(out)
+	}
+	r.placeCursor(out)
+
+	if r.syncOutput {
+		_, _ = out.WriteString(endSynchronizedUpdate)
+	}
+
+	_, _ = r.out.Write(buf.Bytes())
+	r.buf.Reset()
+
+	if r.traceFlush != nil {
+		r.traceFlush(buf.Len(), time.Since(start))
+	}
+}
+
+// paint paints the frame in the buffer, leaving the cursor at the start of its
+// last line.
+func (r *standardRenderer) paint(out *termenv.Output) {
 	newLines := strings.Split(r.buf.String(), "\n")
 
 	// If we know the output's height, we can use it to determine how many
 	// lines we can render. We drop lines from the top of the render buffer if
 	// necessary, as we can't navigate the cursor into the terminal's scrollback
 	// buffer.
+	r.linesTrimmed = 0
 	if r.height > 0 && len(newLines) > r.height {
-		newLines = newLines[len(newLines)-r.height:]
+		r.linesTrimmed = len(newLines) - r.height
+		newLines = newLines[r.linesTrimmed:]
 	}
 
 	numLinesThisFlush := len(newLines)
+	flushQueuedMessages := len(r.queuedMessageLines) > 0 && !r.altScreenActive
+
+	// The cell renderer can only diff against a frame that's still on the
+	// screen as we painted it. Queued messages and ignored lines both
+	// disturb that, in which case we fall back to painting whole lines.
+	if r.useCellRenderer && r.lastCells != nil && !flushQueuedMessages && len(r.ignoreLines) == 0 {
+		cells := parseCellLines(newLines, r.width)
+		renderCellDiff(out, r.lastCells, cells, r.width)
+		r.lastCells = cells
+	} else {
+		r.flushLines(out, newLines, flushQueuedMessages)
+		if r.useCellRenderer {
+			r.lastCells = parseCellLines(newLines, r.width)
+		}
+	}
+	r.linesRendered = numLinesThisFlush
+
+	// Make sure the cursor is at the start of the last line to keep rendering
+	// behavior consistent.
+	if r.altScreenActive {
+		// This case fixes a bug in macOS terminal. In other terminals the
+		// other case seems to do the job regardless of whether or not we're
+		// using the full terminal window.
+		out.MoveCursor(r.linesRendered, 0)
+	} else {
+		out.CursorBack(r.width)
+	}
+
+	r.lastRender = r.buf.String()
+	r.zones = r.bufZones
+}
+
+// placeCursor moves the cursor from the start of the last line to the
+// position requested with the frame, if any.
+func (r *standardRenderer) placeCursor(out *termenv.Output) {
+	r.placedCursor = r.cursor
+
+	x, y := r.cursor.x, r.cursor.y-r.linesTrimmed
+	if x < 0 || y < 0 || y >= r.linesRendered {
+		// No position was requested, or it's not on the screen.
+		r.placedCursor = noCursor
+		return
+	}
+	if r.width > 0 && x >= r.width {
+		x = r.width - 1
+	}
+
+	if r.altScreenActive {
+		out.MoveCursor(y+1, x+1)
+		return
+	}
+	if up := r.linesRendered - 1 - y; up > 0 {
+		out.CursorUp(up)
+	}
+	if x > 0 {
+		out.CursorForward(x)
+	}
+}
+
+// unplaceCursor moves the cursor back to the start of the last line if it was
+// placed elsewhere by placeCursor.
+func (r *standardRenderer) unplaceCursor(out *termenv.Output) {
+	if r.placedCursor == noCursor {
+		return
+	}
+	y := r.placedCursor.y - r.linesTrimmed
+	r.placedCursor = noCursor
+
+	if r.altScreenActive {
+		out.MoveCursor(r.linesRendered, 0)
+		return
+	}
+	if down := r.linesRendered - 1 - y; down > 0 {
+		out.CursorDown(down)
+	}
+	_, _ = out.WriteString("\r")
+}
+
+// flushLines paints the given lines, skipping lines that haven't changed since
+// the last render.
+func (r *standardRenderer) flushLines(out *termenv.Output, newLines []string, flushQueuedMessages bool) {
 	oldLines := strings.Split(r.lastRender, "\n")
 	skipLines := make(map[int]struct{})
-	flushQueuedMessages := len(r.queuedMessageLines) > 0 && !r.altScreenActive
 
 	// Clear any lines we painted in the last render.
 	if r.linesRendered > 0 {
@@ -252,30 +443,24 @@ func (r *standardRenderer) flush() {
 			}
 		}
 	}
-	r.linesRendered = numLinesThisFlush
-
-	// Make sure the cursor is at the start of the last line to keep rendering
-	// behavior consistent.
-	if r.altScreenActive {
-		// This case fixes a bug in macOS terminal. In other terminals the
-		// other case seems to do the job regardless of whether or not we're
-		// using the full terminal window.
-		out.MoveCursor(r.linesRendered, 0)
-	} else {
-		out.CursorBack(r.width)
-	}
-
-	_, _ = r.out.Write(buf.Bytes())
-	r.lastRender = r.buf.String()
-	r.buf.Reset()
 }
 
-// write writes to the internal buffer. The buffer will be outputted via the
+// Write writes to the internal buffer. The buffer will be outputted via the
 // ticker which calls flush().
-func (r *standardRenderer) write(s string) {
+func (r *standardRenderer) Write(s string) {
+	r.WriteWithCursor(s, noCursor.x, noCursor.y)
+}
+
+// WriteWithCursor writes to the internal buffer along with the position the
+// cursor should be moved to once the frame is painted.
+func (r *standardRenderer) WriteWithCursor(s string, x, y int) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 	r.buf.Reset()
+	r.cursor = cursorPosition{x, y}
+	if x < 0 || y < 0 {
+		r.cursor = noCursor
+	}
 
 	// If an empty string was passed we should clear existing output and
 	// rendering nothing. Rather than introduce additional state to manage
@@ -285,31 +470,42 @@ func (r *standardRenderer) write(s string) {
 		s = " "
 	}
 
+	s, r.bufZones = stripZones(s)
 	_, _ = r.buf.WriteString(s)
 }
 
+// Repaint forces the next flush to repaint the whole frame.
+func (r *standardRenderer) Repaint() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.repaint()
+}
+
+// repaint is like Repaint, for callers that already hold the lock.
 func (r *standardRenderer) repaint() {
 	r.lastRender = ""
+	r.lastCells = nil
 }
 
-func (r *standardRenderer) clearScreen() {
+func (r *standardRenderer) ClearScreen() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.ClearScreen()
 	r.out.MoveCursor(1, 1)
+	r.placedCursor = noCursor
 
 	r.repaint()
 }
 
-func (r *standardRenderer) altScreen() bool {
+func (r *standardRenderer) AltScreen() bool {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	return r.altScreenActive
 }
 
-func (r *standardRenderer) enterAltScreen() {
+func (r *standardRenderer) EnterAltScreen() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -317,6 +513,10 @@ func (r *standardRenderer) enterAltScreen() {
 		return
 	}
 
+	// The terminal restores the cursor position once we exit the alt
+	// screen, so make sure it's where the rendering routine expects it.
+	r.unplaceCursor(r.out)
+
 	r.altScreenActive = true
 	r.out.AltScreen()
 
@@ -324,11 +524,15 @@ func (r *standardRenderer) enterAltScreen() {
 	// alt screen (or alt screen support is disabled, like GNU screen by
 	// default).
 	//
-	// Note: we can't use r.clearScreen() here because the mutex is already
+	// Note: we can't use r.ClearScreen() here because the mutex is already
 	// locked.
 	r.out.ClearScreen()
 	r.out.MoveCursor(1, 1)
 
+	if r.keyboardEnhancements != 0 {
+		_, _ = r.out.WriteString(pushKeyboardEnhancements(r.keyboardEnhancements))
+	}
+
 	// cmd.exe and other terminals keep separate cursor states for the AltScreen
 	// and the main buffer. We have to explicitly reset the cursor visibility
 	// whenever we enter AltScreen.
@@ -341,7 +545,7 @@ func (r *standardRenderer) enterAltScreen() {
 	r.repaint()
 }
 
-func (r *standardRenderer) exitAltScreen() {
+func (r *standardRenderer) ExitAltScreen() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -349,6 +553,13 @@ func (r *standardRenderer) exitAltScreen() {
 		return
 	}
 
+	r.unplaceCursor(r.out)
+
+	// Pop the flags pushed for the alt screen while we're still on it.
+	if r.keyboardEnhancements != 0 {
+		_, _ = r.out.WriteString(popKeyboardEnhancements)
+	}
+
 	r.altScreenActive = false
 	r.out.ExitAltScreen()
 
@@ -364,7 +575,7 @@ func (r *standardRenderer) exitAltScreen() {
 	r.repaint()
 }
 
-func (r *standardRenderer) showCursor() {
+func (r *standardRenderer) ShowCursor() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -372,7 +583,7 @@ func (r *standardRenderer) showCursor() {
 	r.out.ShowCursor()
 }
 
-func (r *standardRenderer) hideCursor() {
+func (r *standardRenderer) HideCursor() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -380,49 +591,49 @@ func (r *standardRenderer) hideCursor() {
 	r.out.HideCursor()
 }
 
-func (r *standardRenderer) enableMouseCellMotion() {
+func (r *standardRenderer) EnableMouseCellMotion() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.EnableMouseCellMotion()
 }
 
-func (r *standardRenderer) disableMouseCellMotion() {
+func (r *standardRenderer) DisableMouseCellMotion() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.DisableMouseCellMotion()
 }
 
-func (r *standardRenderer) enableMouseAllMotion() {
+func (r *standardRenderer) EnableMouseAllMotion() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.EnableMouseAllMotion()
 }
 
-func (r *standardRenderer) disableMouseAllMotion() {
+func (r *standardRenderer) DisableMouseAllMotion() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.DisableMouseAllMotion()
 }
 
-func (r *standardRenderer) enableMouseSGRMode() {
+func (r *standardRenderer) EnableMouseSGRMode() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.EnableMouseExtendedMode()
 }
 
-func (r *standardRenderer) disableMouseSGRMode() {
+func (r *standardRenderer) DisableMouseSGRMode() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	r.out.DisableMouseExtendedMode()
 }
 
-func (r *standardRenderer) enableBracketedPaste() {
+func (r *standardRenderer) EnableBracketedPaste() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -430,7 +641,7 @@ func (r *standardRenderer) enableBracketedPaste() {
 	r.bpActive = true
 }
 
-func (r *standardRenderer) disableBracketedPaste() {
+func (r *standardRenderer) DisableBracketedPaste() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
@@ -438,13 +649,43 @@ func (r *standardRenderer) disableBracketedPaste() {
 	r.bpActive = false
 }
 
-func (r *standardRenderer) bracketedPasteActive() bool {
+func (r *standardRenderer) BracketedPasteActive() bool {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	return r.bpActive
 }
 
+func (r *standardRenderer) EnableReportFocus() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, _ = r.out.WriteString(enableReportFocusSeq)
+	r.reportFocus = true
+}
+
+func (r *standardRenderer) DisableReportFocus() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, _ = r.out.WriteString(disableReportFocusSeq)
+	r.reportFocus = false
+}
+
+func (r *standardRenderer) ReportFocusActive() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.reportFocus
+}
+
+func (r *standardRenderer) EnableSynchronizedOutput() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.syncOutput = true
+}
+
 // setIgnoredLines specifies lines not to be touched by the standard Bubble Tea
 // renderer.
 func (r *standardRenderer) setIgnoredLines(from int, to int) {
@@ -467,6 +708,7 @@ func (r *standardRenderer) setIgnoredLines(from int, to int) {
 		buf := &bytes.Buffer{}
 		out := termenv.NewOutput(buf)
 
+		r.unplaceCursor(out)
 		for i := r.linesRendered - 1; i >= 0; i-- {
 			if _, exists := r.ignoreLines[i]; exists {
 				out.ClearLine()
@@ -518,6 +760,7 @@ func (r *standardRenderer) insertTop(lines []string, topBoundary, bottomBoundary
 
 	// Move cursor back to where the main rendering routine expects it to be
 	out.MoveCursor(r.linesRendered, 0)
+	r.placedCursor = noCursor
 
 	_, _ = r.out.Write(buf.Bytes())
 }
@@ -545,20 +788,27 @@ func (r *standardRenderer) insertBottom(lines []string, topBoundary, bottomBound
 
 	// Move cursor back to where the main rendering routine expects it to be
 	out.MoveCursor(r.linesRendered, 0)
+	r.placedCursor = noCursor
 
 	_, _ = r.out.Write(buf.Bytes())
 }
 
-// handleMessages handles internal messages for the renderer.
-func (r *standardRenderer) handleMessages(msg Msg) {
-	switch msg := msg.(type) {
-	case repaintMsg:
-		// Force a repaint by clearing the render cache as we slide into a
-		// render.
-		r.mtx.Lock()
-		r.repaint()
-		r.mtx.Unlock()
+// ZonesAt returns the IDs of the zones of the last frame at the given position
+// on the screen, innermost first. Zones are only reported in the alternate
+// screen, since we don't know where inline frames are on the screen.
+func (r *standardRenderer) ZonesAt(x, y int) []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
 
+	if !r.altScreenActive {
+		return nil
+	}
+	return zonesAt(r.zones, x, y+r.linesTrimmed)
+}
+
+// HandleMessage handles internal messages for the renderer.
+func (r *standardRenderer) HandleMessage(msg Msg) {
+	switch msg := msg.(type) {
 	case WindowSizeMsg:
 		r.mtx.Lock()
 		r.width = msg.Width
@@ -591,16 +841,20 @@ func (r *standardRenderer) handleMessages(msg Msg) {
 
 	case scrollDownMsg:
 		r.insertBottom(msg.lines, msg.topBoundary, msg.bottomBoundary)
+	}
+}
 
-	case printLineMessage:
-		if !r.altScreenActive {
-			lines := strings.Split(msg.messageBody, "\n")
-			r.mtx.Lock()
-			r.queuedMessageLines = append(r.queuedMessageLines, lines...)
-			r.repaint()
-			r.mtx.Unlock()
-		}
+// PrintLine queues a message to be printed above the Program on the next
+// render. Nothing is printed while the alternate screen is active.
+func (r *standardRenderer) PrintLine(message string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.altScreenActive {
+		return
 	}
+	r.queuedMessageLines = append(r.queuedMessageLines, strings.Split(message, "\n")...)
+	r.repaint()
 }
 
 // HIGH-PERFORMANCE RENDERING STUFF
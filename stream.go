@@ -0,0 +1,117 @@
+package tea
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// StreamDoneMsg is sent when a stream command ends without an error, after
+// all of its messages.
+type StreamDoneMsg struct {
+	// ID is the ID of the stream.
+	ID string
+}
+
+// StreamErrMsg is sent when a stream command ends with an error, after all of
+// its messages.
+type StreamErrMsg struct {
+	// ID is the ID of the stream.
+	ID string
+
+	// Err is the error the stream ended with.
+	Err error
+}
+
+// Stream produces a command that sends any number of messages to Update. The
+// given iterator function sends each message with yield, which blocks until
+// the message is received and reports whether the program is still running.
+// When the function returns, a StreamDoneMsg is sent, or a StreamErrMsg if it
+// returned an error. The ID is used to tell streams apart in these messages.
+//
+//	cmd := tea.Stream("progress", func(ctx context.Context, yield func(tea.Msg) bool) error {
+//	    for i := 1; i <= 100; i++ {
+//	        if err := step(ctx, i); err != nil {
+//	            return err
+//	        }
+//	        if !yield(progressMsg(i)) {
+//	            return nil
+//	        }
+//	    }
+//	    return nil
+//	})
+//
+// The context is cancelled when the program exits, after which no more
+// messages are delivered. A stream counts as a single command for the whole
+// time it runs, including for WithMaxConcurrentCommands.
+func Stream(id string, fn func(ctx context.Context, yield func(Msg) bool) error) Cmd {
+	return func() Msg {
+		return streamMsg{id: id, fn: fn}
+	}
+}
+
+// StreamChan produces a stream command that sends the messages received from a
+// channel, until the channel is closed.
+func StreamChan(id string, ch <-chan Msg) Cmd {
+	return Stream(id, func(ctx context.Context, yield func(Msg) bool) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case msg, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				if !yield(msg) {
+					return nil
+				}
+			}
+		}
+	})
+}
+
+// StreamLines produces a stream command that reads lines from r and sends the
+// message fn returns for each of them, without the end-of-line marker. The
+// stream ends at the end of r, or with a StreamErrMsg if reading fails.
+//
+//	cmd := tea.StreamLines("build", stdout, func(line string) tea.Msg {
+//	    return logLineMsg(line)
+//	})
+//
+// A read that's in progress when the program exits can't be interrupted, so
+// the command returns once the read does. Close r to stop it early.
+func StreamLines(id string, r io.Reader, fn func(line string) Msg) Cmd {
+	return Stream(id, func(ctx context.Context, yield func(Msg) bool) error {
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			if ctx.Err() != nil || !yield(fn(s.Text())) {
+				return nil
+			}
+		}
+		return s.Err()
+	})
+}
+
+// streamMsg is used internally to run a stream command.
+type streamMsg struct {
+	id string
+	fn func(ctx context.Context, yield func(Msg) bool) error
+}
+
+// runStream runs a stream command, sending its messages to the program, and
+// returns the message that ends the stream.
+func (p *Program) runStream(ctx context.Context, s streamMsg) Msg {
+	yield := func(msg Msg) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case p.msgs <- msg:
+			return true
+		}
+	}
+
+	if err := s.fn(ctx, yield); err != nil {
+		return StreamErrMsg{ID: s.id, Err: err}
+	}
+	return StreamDoneMsg{ID: s.id}
+}
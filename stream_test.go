@@ -0,0 +1,113 @@
+package tea
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type streamModel struct {
+	init Cmd
+	msgs []Msg
+}
+
+func (m *streamModel) Init() Cmd { return m.init }
+
+func (m *streamModel) Update(msg Msg) (Model, Cmd) {
+	m.msgs = append(m.msgs, msg)
+	switch msg.(type) {
+	case StreamDoneMsg, StreamErrMsg:
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m *streamModel) View() string { return "" }
+
+func runStreamModel(t *testing.T, cmd Cmd) []Msg {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &streamModel{init: cmd}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return m.msgs
+}
+
+func TestStreamLines(t *testing.T) {
+	type lineMsg string
+	r := strings.NewReader("one\ntwo\r\nthree")
+	msgs := runStreamModel(t, StreamLines("lines", r, func(line string) Msg {
+		return lineMsg(line)
+	}))
+
+	expected := []Msg{lineMsg("one"), lineMsg("two"), lineMsg("three"), StreamDoneMsg{ID: "lines"}}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected %v, got %v", expected, msgs)
+	}
+}
+
+func TestStreamChan(t *testing.T) {
+	ch := make(chan Msg, 3)
+	ch <- incrementMsg{}
+	ch <- incrementMsg{}
+	close(ch)
+	msgs := runStreamModel(t, StreamChan("chan", ch))
+
+	expected := []Msg{incrementMsg{}, incrementMsg{}, StreamDoneMsg{ID: "chan"}}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected %v, got %v", expected, msgs)
+	}
+}
+
+func TestStreamErr(t *testing.T) {
+	errBroken := errors.New("broken")
+	msgs := runStreamModel(t, Stream("err", func(ctx context.Context, yield func(Msg) bool) error {
+		yield(incrementMsg{})
+		return errBroken
+	}))
+
+	expected := []Msg{incrementMsg{}, StreamErrMsg{ID: "err", Err: errBroken}}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected %v, got %v", expected, msgs)
+	}
+}
+
+func TestStreamCancelled(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	started, stopped := make(chan struct{}), make(chan bool, 1)
+	m := &initCmdModel{init: Stream("forever", func(ctx context.Context, yield func(Msg) bool) error {
+		close(started)
+		for yield(nil) {
+		}
+		stopped <- ctx.Err() != nil
+		return nil
+	})}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithShutdownTimeout(time.Second))
+	go func() {
+		<-started
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cancelled := <-stopped:
+		if !cancelled {
+			t.Error("expected the stream's context to be cancelled")
+		}
+	default:
+		t.Fatal("expected the stream to stop when the program quit")
+	}
+}
@@ -0,0 +1,148 @@
+package tea
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+// Sub is a subscription to a source of messages, such as a ticker, a file
+// watcher or a socket. Models declare the subscriptions they need with
+// Subscriptions, and the program runs them for as long as they're declared.
+type Sub struct {
+	// ID identifies the subscription across updates. A subscription keeps
+	// running for as long as a subscription with the same ID is declared,
+	// even if its Run function changes.
+	ID string
+
+	// Run sends messages to the program with send until ctx is cancelled,
+	// which happens when the subscription is no longer declared or when the
+	// program exits. Messages sent after the subscription was cancelled are
+	// discarded.
+	Run func(ctx context.Context, send func(Msg))
+}
+
+// SubscriptionModel is an optional interface for models with subscriptions.
+// After Init and after every Update, the program compares the subscriptions
+// returned by Subscriptions with the running ones, by ID: new subscriptions
+// are started and the ones that are missing are cancelled.
+//
+//	func (m model) Subscriptions() []tea.Sub {
+//	    if m.paused {
+//	        return nil
+//	    }
+//	    return []tea.Sub{
+//	        tea.TickSub("clock", time.Second, func(t time.Time) tea.Msg {
+//	            return tickMsg(t)
+//	        }),
+//	    }
+//	}
+//
+// Because a subscription is identified by its ID, declaring it again doesn't
+// start it twice, unlike a Tick command returned again from Update.
+type SubscriptionModel interface {
+	Model
+
+	// Subscriptions returns the subscriptions the model needs in its
+	// current state.
+	Subscriptions() []Sub
+}
+
+// TickSub returns a subscription that sends a message every time the given
+// duration elapses. To produce the messages, pass a function which returns a
+// message containing the time at which the tick occurred.
+func TickSub(id string, d time.Duration, fn func(time.Time) Msg) Sub {
+	return Sub{
+		ID: id,
+		Run: func(ctx context.Context, send func(Msg)) {
+			t := time.NewTicker(d)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-t.C:
+					send(fn(now))
+				}
+			}
+		},
+	}
+}
+
+// updateSubscriptions starts the subscriptions the model declares that aren't
+// running yet, and cancels the running ones it no longer declares.
+func (p *Program) updateSubscriptions(model Model) {
+	m, ok := model.(SubscriptionModel)
+	if !ok && len(p.subs) == 0 {
+		return
+	}
+
+	declared := map[string]bool{}
+	if ok {
+		for _, sub := range m.Subscriptions() {
+			if sub.Run == nil || declared[sub.ID] {
+				continue
+			}
+			declared[sub.ID] = true
+			if _, running := p.subs[sub.ID]; !running {
+				p.startSubscription(sub)
+			}
+		}
+	}
+
+	for id, cancel := range p.subs {
+		if !declared[id] {
+			cancel()
+			delete(p.subs, id)
+		}
+	}
+}
+
+// startSubscription runs a subscription until it's cancelled.
+func (p *Program) startSubscription(sub Sub) {
+	if p.subs == nil {
+		p.subs = map[string]context.CancelFunc{}
+	}
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.subs[sub.ID] = cancel
+
+	send := func(msg Msg) {
+		select {
+		case <-ctx.Done():
+		case p.msgs <- msg:
+		}
+	}
+
+	p.runningCmds.Add(1)
+	go func() {
+		defer p.runningCmds.Done()
+		defer func() {
+			if p.startupOptions.has(withoutCatchPanics) {
+				return
+			}
+			if r := recover(); r != nil {
+				p.Send(subPanickedMsg{id: sub.ID, ctx: ctx})
+				p.panicked(r, debug.Stack())
+			}
+		}()
+		sub.Run(ctx, send)
+	}()
+}
+
+// subPanickedMsg is sent when the Run function of a subscription panics.
+type subPanickedMsg struct {
+	id  string
+	ctx context.Context
+}
+
+// forgetSubscription removes a subscription that panicked from the running
+// ones, so that it's started again on the next update if it's still declared.
+func (p *Program) forgetSubscription(msg subPanickedMsg) {
+	// The subscription was cancelled already, and may have been replaced by
+	// a new one with the same ID.
+	if msg.ctx.Err() != nil {
+		return
+	}
+	p.subs[msg.id]()
+	delete(p.subs, msg.id)
+}
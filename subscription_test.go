@@ -0,0 +1,167 @@
+package tea
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type subTickMsg struct{}
+
+type subModel struct {
+	ticks   int
+	started int32
+	stopped chan struct{}
+}
+
+func (m *subModel) Init() Cmd { return nil }
+
+func (m *subModel) Update(msg Msg) (Model, Cmd) {
+	switch msg.(type) {
+	case subTickMsg:
+		m.ticks++
+	case incrementMsg:
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m *subModel) View() string { return "" }
+
+func (m *subModel) Subscriptions() []Sub {
+	if m.ticks >= 3 {
+		return nil
+	}
+	sub := Sub{
+		ID: "ticker",
+		Run: func(ctx context.Context, send func(Msg)) {
+			atomic.AddInt32(&m.started, 1)
+			defer close(m.stopped)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Millisecond):
+					send(subTickMsg{})
+				}
+			}
+		},
+	}
+	// Declaring a subscription twice doesn't start it twice.
+	return []Sub{sub, sub}
+}
+
+func TestSubscriptions(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &subModel{stopped: make(chan struct{})}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	go func() {
+		<-m.stopped
+		p.Send(incrementMsg{})
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if m.ticks != 3 {
+		t.Errorf("expected 3 ticks before the subscription stopped, got %d", m.ticks)
+	}
+	if n := atomic.LoadInt32(&m.started); n != 1 {
+		t.Errorf("expected the subscription to be started once, got %d", n)
+	}
+}
+
+func TestSubscriptionsCancelledOnQuit(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &subModel{stopped: make(chan struct{})}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithShutdownTimeout(time.Second))
+	go p.Quit()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-m.stopped:
+	default:
+		t.Fatal("expected the subscription to be cancelled when the program quit")
+	}
+}
+
+func TestTickSub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := make(chan Msg)
+	sub := TickSub("tick", time.Millisecond, func(time.Time) Msg {
+		return subTickMsg{}
+	})
+	go sub.Run(ctx, func(msg Msg) {
+		select {
+		case msgs <- msg:
+		case <-ctx.Done():
+		}
+	})
+
+	if _, ok := (<-msgs).(subTickMsg); !ok {
+		t.Fatal("expected a tick message")
+	}
+}
+
+type panicSubModel struct {
+	started int32
+}
+
+func (m *panicSubModel) Init() Cmd { return nil }
+
+func (m *panicSubModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(subTickMsg); ok {
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m *panicSubModel) View() string { return "" }
+
+func (m *panicSubModel) Subscriptions() []Sub {
+	return []Sub{{
+		ID: "panicky",
+		Run: func(ctx context.Context, send func(Msg)) {
+			if atomic.AddInt32(&m.started, 1) == 1 {
+				panic("boom")
+			}
+			send(subTickMsg{})
+		},
+	}}
+}
+
+func TestSubscriptionRestartedAfterPanic(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &panicSubModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithPanicMsgs())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := p.Run()
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		p.Kill()
+		t.Fatal("expected the subscription to be started again after it panicked")
+	}
+	if n := atomic.LoadInt32(&m.started); n != 2 {
+		t.Errorf("expected the subscription to be started again after it panicked, got %d starts", n)
+	}
+}
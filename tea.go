@@ -20,6 +20,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/muesli/cancelreader"
 	"github.com/muesli/termenv"
@@ -30,6 +31,40 @@ import (
 // ErrProgramKilled is returned by [Program.Run] when the program got killed.
 var ErrProgramKilled = errors.New("program was killed")
 
+// ErrProgramPanic is returned by [Program.Run] when the program recovered from
+// a panic. The error is a *PanicError wrapping ErrProgramPanic.
+var ErrProgramPanic = errors.New("program experienced a panic")
+
+// PanicError is returned by [Program.Run] when the program recovered from a
+// panic. Use errors.Is with ErrProgramPanic to check for it.
+type PanicError struct {
+	// Value is the value the panic was called with.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrProgramPanic, e.Value)
+}
+
+// Unwrap returns ErrProgramPanic.
+func (e *PanicError) Unwrap() error {
+	return ErrProgramPanic
+}
+
+// PanicMsg is sent to Update when a command panics, if the program was started
+// with WithPanicMsgs. The program keeps running.
+type PanicMsg struct {
+	// Value is the value the panic was called with.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
 // Msg contain data from the result of a IO operation. Msgs trigger the update
 // function and, henceforth, the UI.
 type Msg interface{}
@@ -49,6 +84,24 @@ type Model interface {
 	View() string
 }
 
+// CursorModel is an optional interface for models that want to place the
+// terminal cursor. After each frame is rendered the cursor is moved to the
+// position returned by CursorPosition, relative to the top-left corner of the
+// view. This is where input method editors place their candidate windows and
+// where screen readers look, so it's a better choice for text inputs than
+// drawing a fake cursor in the view.
+//
+// If either coordinate is negative the cursor is left where the renderer
+// normally puts it. Note that the cursor is hidden while a Program runs;
+// use the ShowCursor command to make it visible.
+type CursorModel interface {
+	Model
+
+	// CursorPosition returns the position of the cursor, where x is the column
+	// and y is the line of the view.
+	CursorPosition() (x, y int)
+}
+
 // Cmd is an IO operation that returns a message when it's complete. If it's
 // nil it's considered a no-op. Use it for things like HTTP requests, timers,
 // saving and loading from disk, and so on.
@@ -98,6 +151,11 @@ const (
 	// feature is on by default.
 	withoutCatchPanics
 	withoutBracketedPaste
+	withCellRenderer
+	withSynchronizedOutput
+	withoutSynchronizedOutput
+	withReportFocus
+	withPanicMsgs
 )
 
 // channelHandlers manages the series of channels returned by various processes.
@@ -141,10 +199,24 @@ type Program struct {
 	errs     chan error
 	finished chan struct{}
 
+	// mailboxSize is the number of messages msgs can hold, and coalescing
+	// are the rules that drop messages superseded by the next ones. pending
+	// is the message taken from msgs that stopped the coalescing of the
+	// previous one, which is handled next.
+	mailboxSize int
+	coalescing  []CoalesceFunc
+	pending     Msg
+
+	// classify returns the priority class of messages, if priorities are
+	// enabled, and queues holds the messages taken from msgs by class.
+	classify func(Msg) MsgPriority
+	queues   [numPriorities][]Msg
+	queued   int
+
 	// where to send output, this will usually be os.Stdout.
 	output        *termenv.Output
 	restoreOutput func() error
-	renderer      renderer
+	renderer      Renderer
 
 	// where to read inputs from, this will usually be os.Stdin.
 	input io.Reader
@@ -158,10 +230,58 @@ type Program struct {
 	altScreenWasActive bool
 	ignoreSignals      uint32
 
-	bpWasActive bool // was the bracketed paste mode active before releasing the terminal?
+	bpWasActive          bool // was the bracketed paste mode active before releasing the terminal?
+	reportFocusWasActive bool // was focus reporting active before releasing the terminal?
+
+	// gestures recognizes mouse gestures, if enabled.
+	gestures *gestureRecognizer
+
+	// travel is the time-travel debugger, if enabled.
+	travel *timeTravel
+
+	// escTimeout is how long the input reader waits for the rest of an
+	// escape sequence before interpreting what it has.
+	escTimeout time.Duration
+
+	// keyboardEnhancements are the kitty keyboard protocol flags requested
+	// with WithKeyboardEnhancements, and keyboardEnhanced reports whether
+	// they're currently pushed onto the terminal's stack.
+	keyboardEnhancements KeyboardEnhancements
+	keyboardEnhanced     bool
+
+	// maxCmds is the maximum number of commands that run concurrently, if
+	// greater than 0. Commands run by sequences and All take one of the
+	// cmdSlots.
+	maxCmds  int
+	cmdSlots chan struct{}
+
+	// runningCmds tracks the commands and subscriptions in flight, which Run
+	// waits for up to shutdownTimeout when the program exits.
+	runningCmds     sync.WaitGroup
+	shutdownTimeout time.Duration
+
+	// subs cancels the running subscriptions by ID.
+	subs map[string]context.CancelFunc
+
+	// latest is the newest command issued with each key with Latest.
+	latest    map[string]*latestCmd
+	latestMtx sync.Mutex
+
+	// debounced and throttled are the commands waiting for their debounce or
+	// throttle timer, by ID.
+	debounced map[string]*timedCmd
+	throttled map[string]*timedCmd
 
 	filter func(Model, Msg) Msg
 
+	// tracer writes a trace of the messages and commands, if enabled.
+	tracer *tracer
+
+	// middleware wraps the update of the model, and update is the resulting
+	// chain, built when the program starts.
+	middleware []Middleware
+	update     UpdateFunc
+
 	// fps is the frames per second we should set on the renderer, if
 	// applicable,
 	fps int
@@ -180,7 +300,6 @@ type QuitMsg struct{}
 func NewProgram(model Model, opts ...ProgramOption) *Program {
 	p := &Program{
 		initialModel: model,
-		msgs:         make(chan Msg),
 	}
 
 	// Apply all options to the program.
@@ -188,6 +307,8 @@ func NewProgram(model Model, opts ...ProgramOption) *Program {
 		opt(p)
 	}
 
+	p.msgs = make(chan Msg, p.mailboxSize)
+
 	// A context can be provided with a ProgramOption, but if none was provided
 	// we'll use the default background context.
 	if p.ctx == nil {
@@ -196,6 +317,10 @@ func NewProgram(model Model, opts ...ProgramOption) *Program {
 	// Initialize context and teardown channel.
 	p.ctx, p.cancel = context.WithCancel(p.ctx)
 
+	if p.maxCmds > 0 {
+		p.cmdSlots = make(chan struct{}, p.maxCmds)
+	}
+
 	// if no output was set, set it to stdout
 	if p.output == nil {
 		p.output = termenv.DefaultOutput()
@@ -265,6 +390,10 @@ func (p *Program) handleResize() chan struct{} {
 // handleCommands runs commands in a goroutine and sends the result to the
 // program's message channel.
 func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
+	if p.maxCmds > 0 {
+		return p.handleCommandsPool(cmds)
+	}
+
 	ch := make(chan struct{})
 
 	go func() {
@@ -282,11 +411,18 @@ func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
 
 				// Don't wait on these goroutines, otherwise the shutdown
 				// latency would get too large as a Cmd can run for some time
-				// (e.g. tick commands that sleep for half a second). It's not
-				// possible to cancel them so we'll have to leak the goroutine
-				// until Cmd returns.
+				// (e.g. tick commands that sleep for half a second). Commands
+				// created with CmdContext are cancelled on exit, but plain
+				// ones can't be, so we'll have to leak the goroutine until
+				// Cmd returns. Run waits for them if a shutdown timeout is
+				// set.
+				p.runningCmds.Add(1)
 				go func() {
-					msg := cmd() // this can be long.
+					defer p.runningCmds.Done()
+					defer p.recoverPanic()
+					done := p.traceCmd()
+					msg := p.runCmd(p.ctx, cmd) // this can be long.
+					done(msg)
 					p.Send(msg)
 				}()
 			}
@@ -296,130 +432,445 @@ func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
 	return ch
 }
 
+// runSequence runs the commands of a sequence one at a time, in order, and
+// sends their messages to the program. A command that returns a batch or a
+// sequence is only complete once all of the commands it contains, at any
+// depth, have returned, and only then does the next command run.
+func (p *Program) runSequence(cmds sequenceMsg) {
+	for _, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+		p.runNested(cmd)
+	}
+}
+
+// runNested runs a command of a sequence and sends its message to the program.
+// If the command returns a batch or a sequence, runNested returns once all of
+// the commands it contains have returned.
+func (p *Program) runNested(cmd Cmd) {
+	switch msg := p.runLimited(p.ctx, cmd).(type) {
+	case sequenceMsg:
+		p.runSequence(msg)
+
+	case BatchMsg:
+		g, _ := errgroup.WithContext(p.ctx)
+		for _, cmd := range msg {
+			if cmd == nil {
+				continue
+			}
+			cmd := cmd
+			g.Go(func() error {
+				defer p.recoverPanic()
+				p.runNested(cmd)
+				return nil
+			})
+		}
+
+		//nolint:errcheck
+		g.Wait() // wait for all commands from batch msg to finish
+
+	default:
+		p.Send(msg)
+	}
+}
+
+// runLimited runs a command of a sequence or of All, after waiting for one of
+// the slots for concurrent commands if their number is limited.
+func (p *Program) runLimited(ctx context.Context, cmd Cmd) Msg {
+	if p.cmdSlots != nil {
+		select {
+		case <-ctx.Done():
+			return nil
+		case p.cmdSlots <- struct{}{}:
+		}
+		slot := &cmdSlot{slots: p.cmdSlots}
+		defer slot.release()
+		ctx = context.WithValue(ctx, cmdSlotKey{}, slot)
+	}
+	done := p.traceCmd()
+	msg := p.runCmd(ctx, cmd)
+	done(msg)
+	return msg
+}
+
+// runCmd runs a command with the given context and returns its message.
+// Priorities are ignored.
+func (p *Program) runCmd(ctx context.Context, cmd Cmd) Msg {
+	msg := cmd()
+	if m, ok := msg.(priorityCmdMsg); ok {
+		return p.runCmd(ctx, m.cmd)
+	}
+	return p.runCmdMsg(ctx, msg)
+}
+
+// runCmdMsg runs the commands that need the program, which return an internal
+// message that describes them, and returns their message. Commands created
+// with CmdContext are run with the given context, which is the program's
+// context or one derived from it, and stream commands send their messages to
+// the program. Other messages are returned as is.
+func (p *Program) runCmdMsg(ctx context.Context, msg Msg) Msg {
+	switch msg := msg.(type) {
+	case contextCmdMsg:
+		return msg(ctx)
+	case streamMsg:
+		return p.runStream(ctx, msg)
+	case timeoutCmdMsg:
+		return p.runTimeout(ctx, msg)
+	case latestCmdMsg:
+		return p.runLatest(ctx, msg)
+	case allMsg:
+		return p.runAll(ctx, msg)
+	case retryMsg:
+		return p.runRetry(ctx, msg)
+	default:
+		return msg
+	}
+}
+
+// runTimeout runs a command created with WithTimeout.
+func (p *Program) runTimeout(ctx context.Context, msg timeoutCmdMsg) Msg {
+	ctx, cancel := context.WithTimeout(ctx, msg.d)
+	defer cancel()
+
+	// If the command panics, the panic is reported and no message is sent,
+	// rather than onTimeout.
+	res := make(chan Msg, 1)
+	go func() {
+		var m Msg
+		defer func() { res <- m }()
+		defer p.recoverPanic()
+		m = p.runCmd(ctx, msg.cmd)
+	}()
+
+	select {
+	case m := <-res:
+		return m
+	case <-ctx.Done():
+		return msg.onTimeout
+	}
+}
+
+// recoverPanic recovers from a panic in a goroutine started by the program,
+// unless catching panics is disabled. The panic is sent to Update as a
+// PanicMsg if the program was started with WithPanicMsgs, or ends the program
+// with a PanicError otherwise. It must be deferred.
+func (p *Program) recoverPanic() {
+	if p.startupOptions.has(withoutCatchPanics) {
+		return
+	}
+	if r := recover(); r != nil {
+		p.panicked(r, debug.Stack())
+	}
+}
+
+// panicked reports a recovered panic, as a PanicMsg if the program was started
+// with WithPanicMsgs and as a PanicError ending the program otherwise.
+func (p *Program) panicked(r interface{}, stack []byte) {
+	if p.startupOptions.has(withPanicMsgs) {
+		p.Send(PanicMsg{Value: r, Stack: stack})
+		return
+	}
+	p.fatalPanic(r, stack)
+}
+
+// recoverFatalPanic is like recoverPanic, but always ends the program with a
+// PanicError. It's deferred by the goroutines the program can't go on without,
+// such as the input loop.
+func (p *Program) recoverFatalPanic() {
+	if p.startupOptions.has(withoutCatchPanics) {
+		return
+	}
+	if r := recover(); r != nil {
+		p.fatalPanic(r, debug.Stack())
+	}
+}
+
+// fatalPanic ends the program with a PanicError for a panic it can't go on
+// after, such as one in the renderer or in the input loop, even if the program
+// was started with WithPanicMsgs.
+func (p *Program) fatalPanic(r interface{}, stack []byte) {
+	select {
+	case <-p.ctx.Done():
+	case p.errs <- &PanicError{Value: r, Stack: stack}:
+	}
+}
+
+// waitForCommands waits for the commands in flight to return, for up to the
+// shutdown timeout.
+func (p *Program) waitForCommands() {
+	if p.shutdownTimeout <= 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.runningCmds.Wait()
+		close(done)
+	}()
+
+	t := time.NewTimer(p.shutdownTimeout)
+	defer t.Stop()
+	select {
+	case <-done:
+	case <-t.C:
+	}
+}
+
 func (p *Program) disableMouse() {
-	p.renderer.disableMouseCellMotion()
-	p.renderer.disableMouseAllMotion()
-	p.renderer.disableMouseSGRMode()
+	p.renderer.DisableMouseCellMotion()
+	p.renderer.DisableMouseAllMotion()
+	p.renderer.DisableMouseSGRMode()
+}
+
+// render sends the model's view to the renderer, along with the position of
+// the cursor if the model provides one. It returns how long View took.
+func (p *Program) render(model Model) (viewTime time.Duration) {
+	// Show the state picked in the time-travel debugger instead, if it's open.
+	if p.travel != nil && p.travel.active {
+		start := time.Now()
+		view := p.travel.view()
+		viewTime = time.Since(start)
+		p.renderer.Write(p.withoutZones(view))
+		return viewTime
+	}
+
+	start := time.Now()
+	view := model.View()
+	viewTime = time.Since(start)
+	view = p.withoutZones(view)
+
+	if m, ok := model.(CursorModel); ok {
+		if r, ok := p.renderer.(CursorRenderer); ok {
+			x, y := m.CursorPosition()
+			r.WriteWithCursor(view, x, y)
+			return viewTime
+		}
+	}
+	p.renderer.Write(view)
+	return viewTime
+}
+
+// withoutZones strips the zone markers from a view, unless the renderer
+// implements ZoneRenderer and strips them itself.
+func (p *Program) withoutZones(view string) string {
+	if _, ok := p.renderer.(ZoneRenderer); ok {
+		return view
+	}
+	return StripZones(view)
 }
 
 // eventLoop is the central message loop. It receives and handles the default
 // Bubble Tea messages, update the model and triggers redraws.
 func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
+	// Messages derived from the last message, such as mouse gestures, which
+	// are handled before any new message.
+	var derived []Msg
+
 	for {
-		select {
-		case <-p.ctx.Done():
+		// Stop as soon as the program is killed, even if messages are still
+		// waiting.
+		if p.ctx.Err() != nil {
 			return model, nil
+		}
 
-		case err := <-p.errs:
-			return model, err
+		var msg Msg
+		if len(derived) > 0 {
+			msg, derived = derived[0], derived[1:]
+		} else {
+			var (
+				ok  bool
+				err error
+			)
+			if msg, ok, err = p.receive(); !ok {
+				return model, err
+			}
+		}
 
-		case msg := <-p.msgs:
-			// Filter messages.
-			if p.filter != nil {
-				msg = p.filter(model, msg)
+		// Tell mouse events which zones they hit, and recognize gestures.
+		if m, ok := msg.(MouseMsg); ok {
+			if r, ok := p.renderer.(ZoneRenderer); ok {
+				m = MouseMsg(MouseEvent(m).withZones(r.ZonesAt(m.X, m.Y)))
+				msg = m
 			}
-			if msg == nil {
-				continue
+			if p.gestures != nil {
+				derived = append(derived, p.gestures.handle(MouseEvent(m), time.Now())...)
 			}
+		}
 
-			// Handle special internal messages.
-			switch msg := msg.(type) {
-			case QuitMsg:
-				return model, nil
+		// Filter messages.
+		if p.filter != nil {
+			msg = p.filter(model, msg)
+		}
+		if msg == nil {
+			continue
+		}
+		p.traceMsg(msg)
 
-			case clearScreenMsg:
-				p.renderer.clearScreen()
+		// Handle special internal messages.
+		switch msg := msg.(type) {
+		case QuitMsg:
+			return model, nil
 
-			case enterAltScreenMsg:
-				p.renderer.enterAltScreen()
+		case clearScreenMsg:
+			p.renderer.ClearScreen()
 
-			case exitAltScreenMsg:
-				p.renderer.exitAltScreen()
+		case repaintMsg:
+			p.renderer.Repaint()
 
-			case enableMouseCellMotionMsg, enableMouseAllMotionMsg:
-				switch msg.(type) {
-				case enableMouseCellMotionMsg:
-					p.renderer.enableMouseCellMotion()
-				case enableMouseAllMotionMsg:
-					p.renderer.enableMouseAllMotion()
-				}
-				// mouse mode (1006) is a no-op if the terminal doesn't support it.
-				p.renderer.enableMouseSGRMode()
+		case printLineMessage:
+			if r, ok := p.renderer.(LinePrinter); ok {
+				r.PrintLine(msg.messageBody)
+			}
+
+		case enterAltScreenMsg:
+			p.renderer.EnterAltScreen()
+
+		case exitAltScreenMsg:
+			p.renderer.ExitAltScreen()
+
+		case enableMouseCellMotionMsg, enableMouseAllMotionMsg:
+			switch msg.(type) {
+			case enableMouseCellMotionMsg:
+				p.renderer.EnableMouseCellMotion()
+			case enableMouseAllMotionMsg:
+				p.renderer.EnableMouseAllMotion()
+			}
+			// mouse mode (1006) is a no-op if the terminal doesn't support it.
+			p.renderer.EnableMouseSGRMode()
+
+		case disableMouseMsg:
+			p.disableMouse()
+
+		case showCursorMsg:
+			p.renderer.ShowCursor()
 
-			case disableMouseMsg:
-				p.disableMouse()
+		case hideCursorMsg:
+			p.renderer.HideCursor()
 
-			case showCursorMsg:
-				p.renderer.showCursor()
+		case enableBracketedPasteMsg:
+			p.renderer.EnableBracketedPaste()
 
-			case hideCursorMsg:
-				p.renderer.hideCursor()
+		case disableBracketedPasteMsg:
+			p.renderer.DisableBracketedPaste()
 
-			case enableBracketedPasteMsg:
-				p.renderer.enableBracketedPaste()
+		case enableReportFocusMsg:
+			p.renderer.EnableReportFocus()
 
-			case disableBracketedPasteMsg:
-				p.renderer.disableBracketedPaste()
+		case disableReportFocusMsg:
+			p.renderer.DisableReportFocus()
+
+		case modeReportMsg:
+			if msg.mode == modeSynchronizedOutput && msg.supported() &&
+				!p.startupOptions.has(withoutSynchronizedOutput) {
+				p.renderer.EnableSynchronizedOutput()
+			}
 
-			case execMsg:
-				// NB: this blocks.
-				p.exec(msg.cmd, msg.fn)
+		case execMsg:
+			// NB: this blocks.
+			p.exec(msg.cmd, msg.fn)
 
-			case BatchMsg:
-				for _, cmd := range msg {
-					cmds <- cmd
+		case BatchMsg:
+			for _, cmd := range msg {
+				select {
+				case <-p.ctx.Done():
+					return model, nil
+				case cmds <- cmd:
 				}
-				continue
+			}
+			continue
 
-			case sequenceMsg:
-				go func() {
-					// Execute commands one at a time, in order.
-					for _, cmd := range msg {
-						if cmd == nil {
-							continue
-						}
-
-						msg := cmd()
-						if batchMsg, ok := msg.(BatchMsg); ok {
-							g, _ := errgroup.WithContext(p.ctx)
-							for _, cmd := range batchMsg {
-								cmd := cmd
-								g.Go(func() error {
-									p.Send(cmd())
-									return nil
-								})
-							}
-
-							//nolint:errcheck
-							g.Wait() // wait for all commands from batch msg to finish
-							continue
-						}
-
-						p.Send(msg)
-					}
-				}()
+		case contextCmdMsg, priorityCmdMsg, streamMsg, timeoutCmdMsg,
+			latestCmdMsg, allMsg, retryMsg:
+			// These are run by the command handler, which can only get
+			// them here if they were sent to the program directly.
+			select {
+			case <-p.ctx.Done():
+				return model, nil
+			case cmds <- func() Msg { return msg }:
+			}
+			continue
+
+		case debounceMsg:
+			p.debounce(msg)
+			continue
+
+		case throttleMsg:
+			p.throttle(msg, cmds)
+			continue
 
-			case setWindowTitleMsg:
-				p.SetWindowTitle(string(msg))
+		case timerFiredMsg:
+			p.timerFired(msg, cmds)
+			continue
+
+		case latestResultMsg:
+			// Handle the message of the command next, unless it's stale.
+			if p.isLatest(msg) {
+				derived = append([]Msg{msg.msg}, derived...)
 			}
+			continue
+
+		case sequenceMsg:
+			p.runningCmds.Add(1)
+			go func() {
+				defer p.runningCmds.Done()
+				defer p.recoverPanic()
+				p.runSequence(msg)
+			}()
+			continue
+
+		case subPanickedMsg:
+			p.forgetSubscription(msg)
+			continue
+
+		case setWindowTitleMsg:
+			p.SetWindowTitle(string(msg))
+		}
 
-			// Process internal messages for the renderer.
-			if r, ok := p.renderer.(*standardRenderer); ok {
-				r.handleMessages(msg)
+		// Let the time-travel debugger handle its keys.
+		if p.travel != nil {
+			var handled bool
+			if model, handled = p.handleTimeTravel(model, msg); handled {
+				p.updateSubscriptions(model)
+				p.render(model)
+				continue
 			}
+		}
 
-			var cmd Cmd
-			model, cmd = model.Update(msg) // run update
-			cmds <- cmd                    // process command (if any)
-			p.renderer.write(model.View()) // send view to renderer
+		// Process internal messages for the renderer.
+		if r, ok := p.renderer.(MessageHandler); ok {
+			r.HandleMessage(msg)
 		}
+
+		var cmd Cmd
+		start := time.Now()
+		model, cmd = p.update(model, msg) // run update
+		updateTime := time.Since(start)
+		if p.travel != nil {
+			p.travel.record(msg, model)
+		}
+
+		// Process the command, if any.
+		select {
+		case <-p.ctx.Done():
+			return model, nil
+		case cmds <- cmd:
+		}
+		p.updateSubscriptions(model) // start and stop subscriptions
+
+		start = time.Now()
+		viewTime := p.render(model) // send view to renderer
+		p.traceUpdate(msg, updateTime, cmd != nil, viewTime, time.Since(start)-viewTime)
 	}
 }
 
 // Run initializes the program and runs its event loops, blocking until it gets
 // terminated by either [Program.Quit], [Program.Kill], or its signal handler.
 // Returns the final model.
-func (p *Program) Run() (Model, error) {
+func (p *Program) Run() (model Model, err error) {
 	handlers := channelHandlers{}
 	cmds := make(chan Cmd)
 	p.errs = make(chan error)
@@ -477,6 +928,7 @@ func (p *Program) Run() (Model, error) {
 				p.shutdown(true)
 				fmt.Printf("Caught panic:\n\n%s\n\nRestoring terminal...\n\n", r)
 				debug.PrintStack()
+				err = &PanicError{Value: r, Stack: debug.Stack()}
 				return
 			}
 		}()
@@ -484,7 +936,21 @@ func (p *Program) Run() (Model, error) {
 
 	// If no renderer is set use the standard one.
 	if p.renderer == nil {
-		p.renderer = newRenderer(p.output, p.startupOptions.has(withANSICompressor), p.fps)
+		p.renderer = newRenderer(
+			p.output,
+			p.startupOptions.has(withANSICompressor),
+			p.startupOptions.has(withCellRenderer),
+			p.fps,
+		)
+	}
+	if r, ok := p.renderer.(*standardRenderer); ok {
+		r.keyboardEnhancements = p.keyboardEnhancements
+		if p.tracer != nil {
+			r.traceFlush = p.traceFlush
+		}
+		if !p.startupOptions.has(withoutCatchPanics) {
+			r.handlePanic = p.fatalPanic
+		}
 	}
 
 	// Check if output is a TTY before entering raw mode, hiding the cursor and
@@ -495,21 +961,31 @@ func (p *Program) Run() (Model, error) {
 
 	// Honor program startup options.
 	if p.startupOptions&withAltScreen != 0 {
-		p.renderer.enterAltScreen()
+		p.renderer.EnterAltScreen()
 	}
 	if p.startupOptions&withoutBracketedPaste == 0 {
-		p.renderer.enableBracketedPaste()
+		p.renderer.EnableBracketedPaste()
+	}
+	if p.startupOptions.has(withReportFocus) {
+		p.renderer.EnableReportFocus()
 	}
 	if p.startupOptions&withMouseCellMotion != 0 {
-		p.renderer.enableMouseCellMotion()
-		p.renderer.enableMouseSGRMode()
+		p.renderer.EnableMouseCellMotion()
+		p.renderer.EnableMouseSGRMode()
 	} else if p.startupOptions&withMouseAllMotion != 0 {
-		p.renderer.enableMouseAllMotion()
-		p.renderer.enableMouseSGRMode()
+		p.renderer.EnableMouseAllMotion()
+		p.renderer.EnableMouseSGRMode()
+	}
+	if p.startupOptions.has(withSynchronizedOutput) {
+		p.renderer.EnableSynchronizedOutput()
+	} else if !p.startupOptions.has(withoutSynchronizedOutput) {
+		// Use synchronized output if the terminal tells us it supports it.
+		p.queryMode(modeSynchronizedOutput)
 	}
 
 	// Initialize the program.
-	model := p.initialModel
+	p.update = chainMiddleware(p.middleware)
+	model = p.initialModel
 	if initCmd := model.Init(); initCmd != nil {
 		ch := make(chan struct{})
 		handlers.add(ch)
@@ -524,11 +1000,18 @@ func (p *Program) Run() (Model, error) {
 		}()
 	}
 
+	if p.travel != nil {
+		p.travel.record(nil, model)
+	}
+
+	// Start the model's subscriptions.
+	p.updateSubscriptions(model)
+
 	// Start the renderer.
-	p.renderer.start()
+	p.renderer.Start()
 
 	// Render the initial view.
-	p.renderer.write(model.View())
+	p.render(model)
 
 	// Subscribe to user input.
 	if p.input != nil {
@@ -544,13 +1027,13 @@ func (p *Program) Run() (Model, error) {
 	handlers.add(p.handleCommands(cmds))
 
 	// Run event loop, handle updates and draw.
-	model, err := p.eventLoop(model, cmds)
+	model, err = p.eventLoop(model, cmds)
 	killed := p.ctx.Err() != nil
 	if killed {
 		err = ErrProgramKilled
 	} else {
 		// Ensure we rendered the final state of the model.
-		p.renderer.write(model.View())
+		p.render(model)
 	}
 
 	// Tear down.
@@ -571,6 +1054,9 @@ func (p *Program) Run() (Model, error) {
 	// Restore terminal state.
 	p.shutdown(killed)
 
+	// Give the commands still running a chance to finish.
+	p.waitForCommands()
+
 	return model, err
 }
 
@@ -597,7 +1083,8 @@ func (p *Program) Start() error {
 // messages to be injected from outside the program for interoperability
 // purposes.
 //
-// If the program hasn't started yet this will be a blocking operation.
+// If the program hasn't started yet, or if its mailbox is full, this will be a
+// blocking operation. Use TrySend to drop messages instead of waiting.
 // If the program has already been terminated this will be a no-op, so it's safe
 // to send messages after the program has exited.
 func (p *Program) Send(msg Msg) {
@@ -635,9 +1122,9 @@ func (p *Program) Wait() {
 func (p *Program) shutdown(kill bool) {
 	if p.renderer != nil {
 		if kill {
-			p.renderer.kill()
+			p.renderer.Kill()
 		} else {
-			p.renderer.stop()
+			p.renderer.Stop()
 		}
 	}
 
@@ -656,11 +1143,12 @@ func (p *Program) ReleaseTerminal() error {
 	p.waitForReadLoop()
 
 	if p.renderer != nil {
-		p.renderer.stop()
+		p.renderer.Stop()
 	}
 
-	p.altScreenWasActive = p.renderer.altScreen()
-	p.bpWasActive = p.renderer.bracketedPasteActive()
+	p.altScreenWasActive = p.renderer.AltScreen()
+	p.bpWasActive = p.renderer.BracketedPasteActive()
+	p.reportFocusWasActive = p.renderer.ReportFocusActive()
 	return p.restoreTerminalState()
 }
 
@@ -677,16 +1165,19 @@ func (p *Program) RestoreTerminal() error {
 		return err
 	}
 	if p.altScreenWasActive {
-		p.renderer.enterAltScreen()
+		p.renderer.EnterAltScreen()
 	} else {
 		// entering alt screen already causes a repaint.
 		go p.Send(repaintMsg{})
 	}
 	if p.renderer != nil {
-		p.renderer.start()
+		p.renderer.Start()
 	}
 	if p.bpWasActive {
-		p.renderer.enableBracketedPaste()
+		p.renderer.EnableBracketedPaste()
+	}
+	if p.reportFocusWasActive {
+		p.renderer.EnableReportFocus()
 	}
 
 	// If the output is a terminal, it may have been resized while another
@@ -703,9 +1194,9 @@ func (p *Program) RestoreTerminal() error {
 //
 // If the altscreen is active no output will be printed.
 func (p *Program) Println(args ...interface{}) {
-	p.msgs <- printLineMessage{
+	p.Send(printLineMessage{
 		messageBody: fmt.Sprint(args...),
-	}
+	})
 }
 
 // Printf prints above the Program. It takes a format template followed by
@@ -717,7 +1208,7 @@ func (p *Program) Println(args ...interface{}) {
 //
 // If the altscreen is active no output will be printed.
 func (p *Program) Printf(template string, args ...interface{}) {
-	p.msgs <- printLineMessage{
+	p.Send(printLineMessage{
 		messageBody: fmt.Sprintf(template, args...),
-	}
+	})
 }
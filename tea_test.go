@@ -3,6 +3,9 @@ package tea
 import (
 	"bytes"
 	"context"
+	"errors"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -256,6 +259,104 @@ func TestTeaSend(t *testing.T) {
 	p.Send(Quit())
 }
 
+type recordingRenderer struct {
+	nilRenderer
+
+	mtx      sync.Mutex
+	frames   []string
+	msgs     []Msg
+	repaints int
+	printed  []string
+}
+
+func (r *recordingRenderer) Repaint() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.repaints++
+}
+
+func (r *recordingRenderer) PrintLine(message string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.printed = append(r.printed, message)
+}
+
+func (r *recordingRenderer) Write(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.frames = append(r.frames, s)
+}
+
+func (r *recordingRenderer) HandleMessage(msg Msg) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.msgs = append(r.msgs, msg)
+}
+
+func TestTeaCustomRenderer(t *testing.T) {
+	var in bytes.Buffer
+
+	r := &recordingRenderer{}
+	p := NewProgram(&testModel{}, WithInput(&in), WithRenderer(r))
+	go p.Send(incrementMsg{})
+	go func() {
+		for {
+			time.Sleep(time.Millisecond)
+			r.mtx.Lock()
+			n := len(r.msgs)
+			r.mtx.Unlock()
+			if n > 0 {
+				p.Quit()
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.frames) < 2 || r.frames[0] != "success\n" {
+		t.Errorf("expected the views to be written to the renderer, got %q", r.frames)
+	}
+	if _, ok := r.msgs[0].(incrementMsg); !ok {
+		t.Errorf("expected the renderer to handle an incrementMsg, got %T", r.msgs[0])
+	}
+}
+
+func TestTeaCustomRendererRepaintAndPrint(t *testing.T) {
+	var in bytes.Buffer
+
+	r := &recordingRenderer{}
+	p := NewProgram(&testModel{}, WithInput(&in), WithRenderer(r))
+	go func() {
+		p.Send(repaintMsg{})
+		p.Println("hello", "world")
+		p.Printf("%d", 42)
+		for {
+			time.Sleep(time.Millisecond)
+			r.mtx.Lock()
+			n := len(r.printed)
+			r.mtx.Unlock()
+			if n == 2 {
+				p.Quit()
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.repaints != 1 {
+		t.Errorf("expected the renderer to repaint once, got %d", r.repaints)
+	}
+	if want := []string{"helloworld", "42"}; !reflect.DeepEqual(r.printed, want) {
+		t.Errorf("expected the renderer to print %q, got %q", want, r.printed)
+	}
+}
+
 func TestTeaNoRun(t *testing.T) {
 	var buf bytes.Buffer
 	var in bytes.Buffer
@@ -263,3 +364,234 @@ func TestTeaNoRun(t *testing.T) {
 	m := &testModel{}
 	NewProgram(m, WithInput(&in), WithOutput(&buf))
 }
+
+type initCmdModel struct {
+	testModel
+	init Cmd
+}
+
+func (m *initCmdModel) Init() Cmd {
+	return m.init
+}
+
+func TestTeaCmdContext(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	var cancelled atomic.Value
+	started := make(chan struct{})
+	m := &initCmdModel{init: CmdContext(func(ctx context.Context) Msg {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		cancelled.Store(true)
+		return nil
+	})}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithShutdownTimeout(time.Second))
+	go func() {
+		<-started
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Load() == nil {
+		t.Fatal("expected Run to wait for the cancelled command")
+	}
+}
+
+func TestTeaShutdownTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	started := make(chan struct{})
+	m := &initCmdModel{init: func() Msg {
+		close(started)
+		time.Sleep(time.Second)
+		return nil
+	}}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithShutdownTimeout(20*time.Millisecond))
+	go func() {
+		<-started
+		p.Kill()
+	}()
+
+	start := time.Now()
+	if _, err := p.Run(); err != ErrProgramKilled {
+		t.Fatalf("Expected %v, got %v", ErrProgramKilled, err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("expected Run to stop waiting after the timeout, took %v", d)
+	}
+}
+
+func TestTeaCmdPanic(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &initCmdModel{init: func() Msg {
+		panic("boom")
+	}}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+
+	_, err := p.Run()
+	if !errors.Is(err, ErrProgramPanic) {
+		t.Fatalf("Expected %v, got %v", ErrProgramPanic, err)
+	}
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Errorf("expected a PanicError with the panic value and stack, got %#v", err)
+	}
+}
+
+type panicMsgModel struct {
+	testModel
+	panics chan PanicMsg
+}
+
+func (m *panicMsgModel) Init() Cmd {
+	return Sequence(func() Msg {
+		panic("boom")
+	})
+}
+
+func (m *panicMsgModel) Update(msg Msg) (Model, Cmd) {
+	if msg, ok := msg.(PanicMsg); ok {
+		m.panics <- msg
+		return m, Quit
+	}
+	return m, nil
+}
+
+func TestTeaPanicMsgs(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &panicMsgModel{panics: make(chan PanicMsg, 1)}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithPanicMsgs())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-m.panics:
+		if msg.Value != "boom" || len(msg.Stack) == 0 {
+			t.Errorf("expected a PanicMsg with the panic value and stack, got %#v", msg)
+		}
+	default:
+		t.Fatal("expected a PanicMsg")
+	}
+}
+
+// panicWriter panics when it's asked to write a view.
+type panicWriter struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *panicWriter) Write(b []byte) (int, error) {
+	if bytes.Contains(b, []byte("success")) {
+		panic("boom")
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.buf.Write(b)
+}
+
+func TestTeaRendererPanic(t *testing.T) {
+	var in bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&panicWriter{}), WithPanicMsgs())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := p.Run()
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		var perr *PanicError
+		if !errors.As(err, &perr) || perr.Value != "boom" {
+			t.Errorf("expected a PanicError for the renderer's panic, got %#v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the program to stop when the renderer panicked")
+	}
+}
+
+type panicReader struct{}
+
+func (panicReader) Read([]byte) (int, error) {
+	panic("boom")
+}
+
+func TestTeaInputPanic(t *testing.T) {
+	var buf bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(panicReader{}), WithOutput(&buf), WithPanicMsgs())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := p.Run()
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		var perr *PanicError
+		if !errors.As(err, &perr) || perr.Value != "boom" {
+			t.Errorf("expected a PanicError for the input loop's panic, got %#v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the program to stop when the input loop panicked")
+	}
+}
+
+func TestTeaNestedSequences(t *testing.T) {
+	slow := func(msg Msg) Cmd {
+		return func() Msg {
+			time.Sleep(5 * time.Millisecond)
+			return msg
+		}
+	}
+	index := func(msgs []Msg, msg Msg) int {
+		for i, m := range msgs {
+			if m == msg {
+				return i
+			}
+		}
+		t.Fatalf("expected %v in %v", msg, msgs)
+		return -1
+	}
+
+	t.Run("sequence in sequence", func(t *testing.T) {
+		msgs := runCmds(t, 100*time.Millisecond, sequenceMsg{
+			Sequence(Sequence(slow("a"), Sequence(slow("b"))), slow("c")),
+			msgCmd("d"),
+		})
+		if expected := []Msg{"a", "b", "c", "d"}; !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("expected %v, got %v", expected, msgs)
+		}
+	})
+
+	t.Run("batches and sequences", func(t *testing.T) {
+		msgs := runCmds(t, 100*time.Millisecond, sequenceMsg{
+			msgCmd("a"),
+			Batch(slow("b"), msgCmd("c")),
+			Batch(Sequence(slow("d"), msgCmd("e")), Batch(slow("f"), Sequence(msgCmd("g")))),
+			msgCmd("h"),
+		})
+		if len(msgs) != 8 {
+			t.Fatalf("expected 8 messages, got %v", msgs)
+		}
+		if msgs[0] != "a" || msgs[7] != "h" {
+			t.Errorf("expected a first and h last, got %v", msgs)
+		}
+		if b, c := index(msgs, "b"), index(msgs, "c"); b > 2 || c > 2 {
+			t.Errorf("expected the first batch before the second one, got %v", msgs)
+		}
+		if index(msgs, "d") > index(msgs, "e") {
+			t.Errorf("expected d before e, got %v", msgs)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package tea
+
+import (
+	"fmt"
+	"strings"
+)
+
+// travelEntry is a state in the history of the time-travel debugger: a
+// message and the model Update returned for it. The message of the initial
+// model is nil.
+type travelEntry struct {
+	msg   Msg
+	model Model
+}
+
+// timeTravel is the time-travel debugger. It records the last states of the
+// model and, while it's open, shows the view of a past state instead of the
+// current one.
+type timeTravel struct {
+	key  string // toggles the debugger
+	size int    // maximum number of states
+
+	history []travelEntry // oldest first
+	active  bool
+	pos     int // the state shown while the debugger is open
+}
+
+func newTimeTravel(key string, size int) *timeTravel {
+	if size < 2 {
+		size = 2
+	}
+	return &timeTravel{key: key, size: size}
+}
+
+// record adds a state to the history, dropping the oldest one once the
+// history is full.
+func (t *timeTravel) record(msg Msg, model Model) {
+	t.history = append(t.history, travelEntry{msg: msg, model: model})
+	if len(t.history) > t.size {
+		t.history[0] = travelEntry{}
+		t.history = t.history[1:]
+		if t.pos > 0 {
+			t.pos--
+		}
+	}
+}
+
+// current returns the model of the last state.
+func (t *timeTravel) current() Model {
+	return t.history[len(t.history)-1].model
+}
+
+// handleTimeTravel handles a message for the debugger and returns the model
+// the program should continue with. It reports whether the message was
+// handled: the toggle key always is, and so are key and mouse messages,
+// including the gestures recognized from them, while the debugger is open.
+// Other messages keep updating the program as usual.
+func (p *Program) handleTimeTravel(model Model, msg Msg) (Model, bool) {
+	t := p.travel
+
+	switch msg := msg.(type) {
+	case KeyMsg:
+		key := msg.String()
+		if msg.EventType == KeyRelease {
+			// Only presses and repeats are acted on, but the releases of the
+			// keys the debugger takes aren't passed on either.
+			return model, t.active || key == t.key
+		}
+		if !t.active {
+			if key != t.key {
+				return model, false
+			}
+			t.active = true
+			t.pos = len(t.history) - 1
+			return model, true
+		}
+
+		switch key {
+		case t.key, "esc":
+			t.active = false
+
+		case "left", "h":
+			if t.pos > 0 {
+				t.pos--
+			}
+
+		case "right", "l":
+			if t.pos < len(t.history)-1 {
+				t.pos++
+			}
+
+		case "home":
+			t.pos = 0
+
+		case "end":
+			t.pos = len(t.history) - 1
+
+		case "enter":
+			// Continue from the state shown, forgetting the later ones.
+			for i := t.pos + 1; i < len(t.history); i++ {
+				t.history[i] = travelEntry{}
+			}
+			t.history = t.history[:t.pos+1]
+			t.active = false
+			model = t.current()
+
+		case "x", "delete":
+			model = p.dropTimeTravelState()
+		}
+		return model, true
+
+	case MouseMsg, MouseClickMsg, MouseWheelMsg,
+		MouseDragStartMsg, MouseDragMoveMsg, MouseDragEndMsg:
+		return model, t.active
+	}
+
+	return model, false
+}
+
+// dropTimeTravelState removes the message of the state shown by the debugger
+// from the history and replays the later messages from the state before it,
+// without running the commands Update returns. It returns the new current
+// model.
+func (p *Program) dropTimeTravelState() Model {
+	t := p.travel
+
+	// The oldest state has no state before it to replay from.
+	if t.pos == 0 {
+		return t.current()
+	}
+
+	model := t.history[t.pos-1].model
+	later := t.history[t.pos+1:]
+	t.history = t.history[:t.pos]
+	for _, e := range later {
+		model, _ = p.update(model, e.msg)
+		t.history = append(t.history, travelEntry{msg: e.msg, model: model})
+	}
+	if t.pos == len(t.history) {
+		t.pos--
+	}
+	return t.current()
+}
+
+// view returns the view of the state shown by the debugger, with a status
+// line describing it.
+func (t *timeTravel) view() string {
+	e := t.history[t.pos]
+
+	desc := "initial model"
+	if e.msg != nil {
+		desc = fmt.Sprintf("%T %v", e.msg, e.msg)
+		desc = strings.Join(strings.Fields(desc), " ")
+		if r := []rune(desc); len(r) > 40 {
+			desc = string(r[:39]) + "…"
+		}
+	}
+
+	status := fmt.Sprintf("time travel %d/%d: %s · ←/→ step · x drop · enter resume · esc close",
+		t.pos+1, len(t.history), desc)
+	return e.model.View() + "\n" + status
+}
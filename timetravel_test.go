@@ -0,0 +1,193 @@
+package tea
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type counterModel int
+
+func (m counterModel) Init() Cmd { return nil }
+
+func (m counterModel) Update(msg Msg) (Model, Cmd) {
+	switch msg.(type) {
+	case incrementMsg:
+		return m + 1, nil
+	}
+	return m, nil
+}
+
+func (m counterModel) View() string { return fmt.Sprintf("count: %d", int(m)) }
+
+func travelModels(t *timeTravel) []Model {
+	var models []Model
+	for _, e := range t.history {
+		models = append(models, e.model)
+	}
+	return models
+}
+
+func TestTimeTravelHistory(t *testing.T) {
+	tt := newTimeTravel("f12", 3)
+	tt.record(nil, counterModel(0))
+	for i := 1; i <= 4; i++ {
+		tt.record(incrementMsg{}, counterModel(i))
+	}
+
+	expected := []Model{counterModel(2), counterModel(3), counterModel(4)}
+	if models := travelModels(tt); !reflect.DeepEqual(models, expected) {
+		t.Errorf("expected the oldest states to be dropped, got %v", models)
+	}
+}
+
+func TestTimeTravel(t *testing.T) {
+	p := NewProgram(counterModel(0), WithTimeTravel("f12", 10))
+	p.update = chainMiddleware(nil)
+	p.travel.record(nil, counterModel(0))
+	for i := 1; i <= 3; i++ {
+		p.travel.record(incrementMsg{}, counterModel(i))
+	}
+
+	press := func(key KeyMsg) Model {
+		t.Helper()
+		model, handled := p.handleTimeTravel(counterModel(3), key)
+		if !handled {
+			t.Fatalf("expected %q to be handled", key)
+		}
+		return model
+	}
+
+	if _, handled := p.handleTimeTravel(counterModel(3), KeyMsg{Type: KeyEnter}); handled {
+		t.Error("expected keys to be left to the model while the debugger is closed")
+	}
+
+	press(KeyMsg{Type: KeyF12})
+	if !p.travel.active || p.travel.pos != 3 {
+		t.Fatalf("expected the debugger to open on the current state, got %+v", p.travel)
+	}
+
+	press(KeyMsg{Type: KeyLeft})
+	if v := p.travel.view(); !strings.HasPrefix(v, "count: 2\n") || !strings.Contains(v, "3/4") {
+		t.Errorf("expected the view of the previous state, got %q", v)
+	}
+	if _, handled := p.handleTimeTravel(counterModel(3), incrementMsg{}); handled {
+		t.Error("expected other messages to update the program while the debugger is open")
+	}
+
+	// Dropping the second increment replays the third one from the first.
+	if model := press(KeyMsg{Type: KeyRunes, Runes: []rune("x")}); model != counterModel(2) {
+		t.Errorf("expected the replayed model to be current, got %v", model)
+	}
+	expected := []Model{counterModel(0), counterModel(1), counterModel(2)}
+	if models := travelModels(p.travel); !reflect.DeepEqual(models, expected) {
+		t.Errorf("expected %v, got %v", expected, models)
+	}
+
+	press(KeyMsg{Type: KeyHome})
+	if model := press(KeyMsg{Type: KeyEnter}); model != counterModel(0) {
+		t.Errorf("expected to resume from the initial model, got %v", model)
+	}
+	if p.travel.active || len(p.travel.history) != 1 {
+		t.Errorf("expected the debugger to close and forget the later states, got %+v", p.travel)
+	}
+}
+
+func TestTimeTravelKeyRelease(t *testing.T) {
+	p := NewProgram(counterModel(0), WithTimeTravel("f12", 10))
+	p.update = chainMiddleware(nil)
+	p.travel.record(nil, counterModel(0))
+	p.travel.record(incrementMsg{}, counterModel(1))
+	p.travel.record(incrementMsg{}, counterModel(2))
+
+	send := func(key KeyMsg) {
+		t.Helper()
+		if _, handled := p.handleTimeTravel(counterModel(2), key); !handled {
+			t.Fatalf("expected %v to be handled", key)
+		}
+	}
+	press := func(k KeyType) KeyMsg { return KeyMsg{Type: k} }
+	release := func(k KeyType) KeyMsg { return KeyMsg{Type: k, EventType: KeyRelease} }
+
+	send(press(KeyF12))
+	send(release(KeyF12))
+	if !p.travel.active {
+		t.Fatal("expected the release of the key to keep the debugger open")
+	}
+
+	send(press(KeyLeft))
+	send(release(KeyLeft))
+	if p.travel.pos != 1 {
+		t.Errorf("expected a press and a release to step once, got state %d", p.travel.pos)
+	}
+
+	send(press(KeyF12))
+	send(release(KeyF12))
+	if p.travel.active {
+		t.Error("expected the release of the key to keep the debugger closed")
+	}
+}
+
+func TestTimeTravelRun(t *testing.T) {
+	var in bytes.Buffer
+
+	r := &recordingRenderer{}
+	p := NewProgram(counterModel(0), WithInput(&in), WithRenderer(r), WithTimeTravel("f12", 10))
+	go func() {
+		for _, msg := range []Msg{
+			incrementMsg{}, incrementMsg{},
+			KeyMsg{Type: KeyF12}, KeyMsg{Type: KeyLeft}, KeyMsg{Type: KeyEnter},
+			incrementMsg{},
+		} {
+			p.Send(msg)
+		}
+		p.Quit()
+	}()
+
+	model, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model != counterModel(2) {
+		t.Errorf("expected the program to resume from the past state, got %v", model)
+	}
+
+	var shown bool
+	for _, f := range r.frames {
+		if strings.HasPrefix(f, "count: 1\ntime travel 2/3") {
+			shown = true
+		}
+	}
+	if !shown {
+		t.Errorf("expected the past state to be shown, got %q", r.frames)
+	}
+}
+
+func TestTimeTravelMouse(t *testing.T) {
+	p := NewProgram(counterModel(0), WithTimeTravel("f12", 10))
+	p.update = chainMiddleware(nil)
+	p.travel.record(nil, counterModel(0))
+
+	msgs := []Msg{
+		MouseMsg{Action: MouseActionPress, Button: MouseButtonLeft},
+		MouseClickMsg{},
+		MouseWheelMsg{},
+		MouseDragStartMsg{},
+		MouseDragMoveMsg{},
+		MouseDragEndMsg{},
+	}
+	for _, msg := range msgs {
+		if _, handled := p.handleTimeTravel(counterModel(0), msg); handled {
+			t.Errorf("expected %T to be left to the model while the debugger is closed", msg)
+		}
+	}
+
+	p.handleTimeTravel(counterModel(0), KeyMsg{Type: KeyF12})
+	for _, msg := range msgs {
+		if _, handled := p.handleTimeTravel(counterModel(0), msg); !handled {
+			t.Errorf("expected %T to be swallowed while the debugger is open", msg)
+		}
+	}
+}
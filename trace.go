@@ -0,0 +1,142 @@
+package tea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Trace events.
+const (
+	traceMsg      = "msg"
+	traceUpdate   = "update"
+	traceCmdStart = "cmd_start"
+	traceCmdEnd   = "cmd_end"
+	traceFlush    = "flush"
+)
+
+// traceEvent is a line of a trace. Durations are in microseconds.
+type traceEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+
+	// The message, for msg and update events, and the message of the
+	// command, for cmd_end events.
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	// The update of the model, the rendering of its view and the time it
+	// took to hand the view to the renderer, for update events.
+	Update *int64 `json:"update_us,omitempty"`
+	Cmd    *bool  `json:"cmd,omitempty"`
+	View   *int64 `json:"view_us,omitempty"`
+	Write  *int64 `json:"write_us,omitempty"`
+
+	// The command, for cmd_start and cmd_end events.
+	ID uint64 `json:"id,omitempty"`
+
+	// The size of the frame written to the terminal, for flush events.
+	Bytes int `json:"bytes,omitempty"`
+
+	// How long the command ran, for cmd_end events, or how long writing the
+	// frame took, for flush events.
+	Duration *int64 `json:"duration_us,omitempty"`
+}
+
+// tracer writes traces as JSON lines.
+type tracer struct {
+	mtx    sync.Mutex
+	enc    *json.Encoder
+	cmdIDs uint64
+}
+
+func newTracer(w io.Writer) *tracer {
+	return &tracer{enc: json.NewEncoder(w)}
+}
+
+// trace writes an event. Errors are ignored so that tracing can't break the
+// program.
+func (t *tracer) trace(ev traceEvent) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	_ = t.enc.Encode(ev)
+}
+
+// traceMsg traces a message taken by the event loop.
+func (p *Program) traceMsg(msg Msg) {
+	if p.tracer == nil {
+		return
+	}
+	p.tracer.trace(traceEvent{
+		Time:  time.Now(),
+		Event: traceMsg,
+		Type:  fmt.Sprintf("%T", msg),
+		Value: fmt.Sprintf("%v", msg),
+	})
+}
+
+// traceUpdate traces the update of the model with a message and the rendering
+// of the resulting view.
+func (p *Program) traceUpdate(msg Msg, update time.Duration, cmd bool, view, write time.Duration) {
+	if p.tracer == nil {
+		return
+	}
+	p.tracer.trace(traceEvent{
+		Time:   time.Now(),
+		Event:  traceUpdate,
+		Type:   fmt.Sprintf("%T", msg),
+		Update: micros(update),
+		Cmd:    &cmd,
+		View:   micros(view),
+		Write:  micros(write),
+	})
+}
+
+// traceCmd traces the start of a command and returns a function that traces
+// its end with its message.
+func (p *Program) traceCmd() func(Msg) {
+	return p.traceCmdSince(time.Now())
+}
+
+// traceCmdSince is like traceCmd, for a command that started at the given
+// time.
+func (p *Program) traceCmdSince(start time.Time) func(Msg) {
+	if p.tracer == nil {
+		return func(Msg) {}
+	}
+
+	id := atomic.AddUint64(&p.tracer.cmdIDs, 1)
+	p.tracer.trace(traceEvent{Time: start, Event: traceCmdStart, ID: id})
+
+	return func(msg Msg) {
+		end := time.Now()
+		ev := traceEvent{
+			Time:     end,
+			Event:    traceCmdEnd,
+			ID:       id,
+			Duration: micros(end.Sub(start)),
+		}
+		if msg != nil {
+			ev.Type = fmt.Sprintf("%T", msg)
+		}
+		p.tracer.trace(ev)
+	}
+}
+
+// traceFlush traces a frame written to the terminal by the renderer.
+func (p *Program) traceFlush(size int, d time.Duration) {
+	p.tracer.trace(traceEvent{
+		Time:     time.Now(),
+		Event:    traceFlush,
+		Bytes:    size,
+		Duration: micros(d),
+	})
+}
+
+func micros(d time.Duration) *int64 {
+	us := d.Microseconds()
+	return &us
+}
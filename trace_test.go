@@ -0,0 +1,103 @@
+package tea
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// runTraced runs a program whose Init command sends a key, which makes it
+// quit, and returns the events of its trace by kind.
+func runTraced(t *testing.T, init Cmd, opts ...ProgramOption) map[string][]traceEvent {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var in bytes.Buffer
+	var trace bytes.Buffer
+
+	m := &initCmdModel{init: init}
+	opts = append(opts, WithInput(&in), WithOutput(&buf), WithTrace(&trace), WithShutdownTimeout(time.Second))
+	p := NewProgram(m, opts...)
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	events := map[string][]traceEvent{}
+	s := bufio.NewScanner(&trace)
+	for s.Scan() {
+		var ev traceEvent
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid trace line %q: %v", s.Text(), err)
+		}
+		events[ev.Event] = append(events[ev.Event], ev)
+	}
+	return events
+}
+
+func keyCmd() Msg {
+	return KeyMsg{Type: KeyEnter}
+}
+
+func TestTrace(t *testing.T) {
+	events := runTraced(t, keyCmd)
+
+	var msg *traceEvent
+	for i, ev := range events[traceMsg] {
+		if ev.Type == "tea.KeyMsg" {
+			msg = &events[traceMsg][i]
+		}
+	}
+	if msg == nil {
+		t.Fatalf("expected the key message to be traced, got %v", events[traceMsg])
+	}
+	if msg.Value != "enter" {
+		t.Errorf("expected the value of the key message to be traced, got %q", msg.Value)
+	}
+
+	var update *traceEvent
+	for i, ev := range events[traceUpdate] {
+		if ev.Type == "tea.KeyMsg" {
+			update = &events[traceUpdate][i]
+		}
+	}
+	if update == nil {
+		t.Fatalf("expected the update with the key message to be traced, got %v", events[traceUpdate])
+	}
+	if update.Update == nil || update.View == nil || update.Write == nil {
+		t.Error("expected the update to be traced with its durations")
+	}
+	if update.Cmd == nil || !*update.Cmd {
+		t.Error("expected the update to be traced with a command")
+	}
+
+	starts, ends := events[traceCmdStart], events[traceCmdEnd]
+	if len(starts) == 0 || len(starts) != len(ends) {
+		t.Fatalf("expected every command to start and end, got %d starts and %d ends", len(starts), len(ends))
+	}
+	for _, ev := range ends {
+		if ev.ID == 0 || ev.Duration == nil {
+			t.Errorf("expected the end of the command to be traced with its ID and duration, got %+v", ev)
+		}
+	}
+
+	flushes := events[traceFlush]
+	if len(flushes) == 0 {
+		t.Fatal("expected the frames written to the terminal to be traced")
+	}
+	if ev := flushes[0]; ev.Bytes == 0 || ev.Duration == nil {
+		t.Errorf("expected the frame to be traced with its size and duration, got %+v", ev)
+	}
+}
+
+func TestTracePriority(t *testing.T) {
+	events := runTraced(t, WithPriority(keyCmd, 1), WithMaxConcurrentCommands(1))
+
+	// The command that gives keyCmd its priority isn't traced on its own.
+	for _, ev := range events[traceCmdEnd] {
+		if ev.Type == "" {
+			t.Errorf("expected only commands with a message to be traced, got %+v", ev)
+		}
+	}
+}
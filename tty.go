@@ -16,25 +16,61 @@ func (p *Program) initTerminal() error {
 		return err
 	}
 
-	p.renderer.hideCursor()
+	p.renderer.HideCursor()
+	p.enableKeyboardEnhancements()
 	return nil
 }
 
+// enableKeyboardEnhancements pushes the keyboard enhancement flags requested
+// with WithKeyboardEnhancements, if any. Terminals that don't support the
+// kitty keyboard protocol ignore them.
+func (p *Program) enableKeyboardEnhancements() {
+	if p.keyboardEnhancements == 0 || p.keyboardEnhanced {
+		return
+	}
+	_, _ = p.output.WriteString(pushKeyboardEnhancements(p.keyboardEnhancements))
+	p.keyboardEnhanced = true
+}
+
+// disableKeyboardEnhancements pops the keyboard enhancement flags pushed by
+// enableKeyboardEnhancements, restoring the terminal's previous flags.
+func (p *Program) disableKeyboardEnhancements() {
+	if !p.keyboardEnhanced {
+		return
+	}
+	_, _ = p.output.WriteString(popKeyboardEnhancements)
+	p.keyboardEnhanced = false
+}
+
+// queryMode asks the terminal to report the setting of the given private mode.
+// Terminals that recognize the request reply with a report that the input
+// reader turns into a modeReportMsg. Terminals that don't will ignore it.
+func (p *Program) queryMode(mode int) {
+	if !p.canQueryTerminal() {
+		return
+	}
+	_, _ = p.output.WriteString(requestMode(mode))
+}
+
 // restoreTerminalState restores the terminal to the state prior to running the
 // Bubble Tea program.
 func (p *Program) restoreTerminalState() error {
 	if p.renderer != nil {
-		p.renderer.disableBracketedPaste()
-		p.renderer.showCursor()
+		p.renderer.DisableBracketedPaste()
+		if p.renderer.ReportFocusActive() {
+			p.renderer.DisableReportFocus()
+		}
+		p.renderer.ShowCursor()
 		p.disableMouse()
 
-		if p.renderer.altScreen() {
-			p.renderer.exitAltScreen()
+		if p.renderer.AltScreen() {
+			p.renderer.ExitAltScreen()
 
 			// give the terminal a moment to catch up
 			time.Sleep(time.Millisecond * 10) //nolint:gomnd
 		}
 	}
+	p.disableKeyboardEnhancements()
 
 	return p.restoreInput()
 }
@@ -65,8 +101,9 @@ func (p *Program) initCancelReader() error {
 
 func (p *Program) readLoop() {
 	defer close(p.readLoopDone)
+	defer p.recoverFatalPanic()
 
-	err := readInputs(p.ctx, p.msgs, p.cancelReader)
+	err := readInputs(p.ctx, p.msgs, p.cancelReader, p.escTimeout)
 	if !errors.Is(err, io.EOF) && !errors.Is(err, cancelreader.ErrCanceled) {
 		select {
 		case <-p.ctx.Done():
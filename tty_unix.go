@@ -23,6 +23,13 @@ func (p *Program) initInput() (err error) {
 	return nil
 }
 
+// canQueryTerminal reports whether we can send queries to the terminal and
+// read its replies, that is, whether both input and output are terminals.
+func (p *Program) canQueryTerminal() bool {
+	f, ok := p.output.TTY().(*os.File)
+	return p.tty != nil && ok && term.IsTerminal(int(f.Fd()))
+}
+
 func openInputTTY() (*os.File, error) {
 	f, err := os.Open("/dev/tty")
 	if err != nil {
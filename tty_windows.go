@@ -36,6 +36,13 @@ func (p *Program) initInput() (err error) {
 	return
 }
 
+// canQueryTerminal reports whether we can send queries to the terminal and
+// read its replies. The console input reader would deliver replies as key
+// events, so we never query the terminal on Windows.
+func (p *Program) canQueryTerminal() bool {
+	return false
+}
+
 // Open the Windows equivalent of a TTY.
 func openInputTTY() (*os.File, error) {
 	f, err := os.OpenFile("CONIN$", os.O_RDWR, 0644)
@@ -0,0 +1,176 @@
+package tea
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/reflow/ansi"
+)
+
+// Zone markers are CSI sequences that terminals don't use, so they're
+// zero-width to the libraries that measure and lay out views. The zone ID is
+// encoded in the parameters as the code points of its runes:
+//
+//	CSI > 1 ; id z    start of a zone
+//	CSI > 2 ; id z    end of a zone
+const (
+	zoneMarkerPrefix = "\x1b[>"
+	zoneStart        = "1"
+	zoneEnd          = "2"
+)
+
+var zoneMarkerRe = regexp.MustCompile(`\x1b\[>([12]);([\d:]*)z`)
+
+// Zone marks s as a mouse zone with the given ID. Zones are regions of a view
+// that can be hit by the mouse, such as buttons or list items: when the mouse
+// is used over a zone, the Zones of the MouseMsg sent to Update include its
+// ID. IDs can't contain NUL characters.
+//
+//	func (m model) View() string {
+//	    return tea.Zone("ok", okButton) + " " + tea.Zone("cancel", cancelButton)
+//	}
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case tea.MouseMsg:
+//	        if msg.Action == tea.MouseActionRelease && msg.InZone("ok") {
+//	            // ...
+//	        }
+//	    }
+//	    // ...
+//	}
+//
+// The markers Zone adds are invisible and zero-width, so zones can be laid out
+// like any other string and nested within each other. The renderer strips
+// them before painting, see StripZones. A zone's area is the rectangle between the start and
+// the end of s on the screen, so a multi-line zone should be a block of even
+// width, like the ones produced by Lip Gloss.
+//
+// Zones are only reported while the program uses the alternate screen buffer,
+// where the position of the view on the screen is known.
+func Zone(id, s string) string {
+	return zoneMarker(zoneStart, id) + s + zoneMarker(zoneEnd, id)
+}
+
+// zoneMarker returns the marker of the given kind for a zone ID.
+func zoneMarker(kind, id string) string {
+	var b strings.Builder
+	b.WriteString(zoneMarkerPrefix)
+	b.WriteString(kind)
+	b.WriteByte(';')
+	for i, r := range []rune(id) {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(strconv.Itoa(int(r)))
+	}
+	b.WriteByte('z')
+	return b.String()
+}
+
+// zone is the area of a mouse zone on a frame. Start is inclusive and end is
+// exclusive on the x axis, and both are inclusive on the y axis.
+type zone struct {
+	id             string
+	startX, startY int
+	endX, endY     int
+}
+
+// contains reports whether the zone contains the given position of its frame.
+func (z zone) contains(x, y int) bool {
+	x0, x1 := z.startX, z.endX
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	y0, y1 := z.startY, z.endY
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	return x >= x0 && x < x1 && y >= y0 && y <= y1
+}
+
+// StripZones removes the zone markers added by Zone from a view. Renderers
+// that implement ZoneRenderer are passed views with their markers, and must
+// strip them before painting.
+func StripZones(view string) string {
+	if !strings.Contains(view, zoneMarkerPrefix) {
+		return view
+	}
+	return zoneMarkerRe.ReplaceAllString(view, "")
+}
+
+// stripZones removes the zone markers from a view and returns the zones they
+// delimit, in the order the zones start.
+func stripZones(s string) (string, []zone) {
+	if !strings.Contains(s, zoneMarkerPrefix) {
+		return s, nil
+	}
+	matches := zoneMarkerRe.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s, nil
+	}
+
+	var (
+		b     strings.Builder
+		zones []zone
+		open  = map[string][]int{} // indices of the open zones by ID
+		x, y  int
+		last  int
+	)
+	advance := func(text string) {
+		b.WriteString(text)
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			y += strings.Count(text, "\n")
+			x = ansi.PrintableRuneWidth(text[i+1:])
+			return
+		}
+		x += ansi.PrintableRuneWidth(text)
+	}
+
+	for _, m := range matches {
+		advance(s[last:m[0]])
+		last = m[1]
+
+		var id []rune
+		if params := s[m[4]:m[5]]; params != "" {
+			for _, p := range strings.Split(params, ":") {
+				r, err := strconv.Atoi(p)
+				if err != nil {
+					continue
+				}
+				id = append(id, rune(r))
+			}
+		}
+		key := string(id)
+
+		switch s[m[2]:m[3]] {
+		case zoneStart:
+			open[key] = append(open[key], len(zones))
+			zones = append(zones, zone{id: key, startX: x, startY: y, endX: x, endY: y})
+		case zoneEnd:
+			stack := open[key]
+			if len(stack) == 0 {
+				continue
+			}
+			i := stack[len(stack)-1]
+			open[key] = stack[:len(stack)-1]
+			zones[i].endX, zones[i].endY = x, y
+		}
+	}
+	advance(s[last:])
+
+	return b.String(), zones
+}
+
+// zonesAt returns the IDs of the zones that contain the given position,
+// innermost first.
+func zonesAt(zones []zone, x, y int) []string {
+	var ids []string
+	for i := len(zones) - 1; i >= 0; i-- {
+		if zones[i].contains(x, y) {
+			ids = append(ids, zones[i].id)
+		}
+	}
+	return ids
+}
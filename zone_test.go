@@ -0,0 +1,159 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestStripZones(t *testing.T) {
+	tests := []struct {
+		name     string
+		view     string
+		expected string
+		zones    []zone
+	}{
+		{
+			name:     "no zones",
+			view:     "hello\nworld",
+			expected: "hello\nworld",
+		},
+		{
+			name:     "single line",
+			view:     "ab " + Zone("ok", "[ok]") + " " + Zone("cancel", "[cancel]"),
+			expected: "ab [ok] [cancel]",
+			zones: []zone{
+				{id: "ok", startX: 3, endX: 7},
+				{id: "cancel", startX: 8, endX: 16},
+			},
+		},
+		{
+			name:     "styles and wide runes",
+			view:     "\x1b[1m日本\x1b[0m" + Zone("ü", "\x1b[31mx\x1b[0m"),
+			expected: "\x1b[1m日本\x1b[0m\x1b[31mx\x1b[0m",
+			zones: []zone{
+				{id: "ü", startX: 4, endX: 5},
+			},
+		},
+		{
+			name:     "multiple lines",
+			view:     "title\n  " + Zone("box", "abc\n  def") + "\nfooter",
+			expected: "title\n  abc\n  def\nfooter",
+			zones: []zone{
+				{id: "box", startX: 2, startY: 1, endX: 5, endY: 2},
+			},
+		},
+		{
+			name:     "nested",
+			view:     Zone("list", Zone("item", "a")+Zone("item", "b")),
+			expected: "ab",
+			zones: []zone{
+				{id: "list", startX: 0, endX: 2},
+				{id: "item", startX: 0, endX: 1},
+				{id: "item", startX: 1, endX: 2},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			view, zones := stripZones(test.view)
+			if view != test.expected {
+				t.Errorf("expected view %q, got %q", test.expected, view)
+			}
+			if !reflect.DeepEqual(zones, test.zones) {
+				t.Errorf("expected zones %+v, got %+v", test.zones, zones)
+			}
+			if view := StripZones(test.view); view != test.expected {
+				t.Errorf("expected StripZones to return %q, got %q", test.expected, view)
+			}
+		})
+	}
+}
+
+func TestZonesAt(t *testing.T) {
+	_, zones := stripZones(Zone("list", Zone("a", "aa")+"\n"+Zone("b", "bb")))
+	for _, tc := range []struct {
+		x, y     int
+		expected []string
+	}{
+		{0, 0, []string{"a", "list"}},
+		{1, 1, []string{"b", "list"}},
+		{2, 0, nil},
+		{0, 2, nil},
+	} {
+		if got := zonesAt(zones, tc.x, tc.y); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected zones %v at (%d, %d), got %v", tc.expected, tc.x, tc.y, got)
+		}
+	}
+}
+
+func TestRendererZones(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, false, defaultFPS).(*standardRenderer)
+	r.HandleMessage(WindowSizeMsg{Width: 10, Height: 2})
+	r.Write("top\n" + Zone("btn", "[ok]") + "\nbottom")
+
+	// Nothing's painted yet.
+	r.EnterAltScreen()
+	if zones := r.ZonesAt(0, 0); zones != nil {
+		t.Errorf("expected no zones before painting, got %v", zones)
+	}
+
+	// The top line doesn't fit the screen and isn't painted.
+	r.flush()
+	if bytes.Contains(buf.Bytes(), []byte(zoneMarkerPrefix)) {
+		t.Errorf("expected zone markers to be stripped, got %q", buf.String())
+	}
+	if zones := r.ZonesAt(1, 0); !reflect.DeepEqual(zones, []string{"btn"}) {
+		t.Errorf("expected to hit btn, got %v", zones)
+	}
+
+	// Zones aren't reported inline.
+	r.ExitAltScreen()
+	if zones := r.ZonesAt(1, 0); zones != nil {
+		t.Errorf("expected no zones inline, got %v", zones)
+	}
+}
+
+func TestMouseEventZones(t *testing.T) {
+	m := MouseMsg(MouseEvent{X: 1}.withZones([]string{"a", "list"}))
+	if !reflect.DeepEqual(m.Zones(), []string{"a", "list"}) {
+		t.Errorf("expected zones [a list], got %v", m.Zones())
+	}
+	if !m.InZone("list") || m.InZone("b") {
+		t.Errorf("expected to be in list and not in b")
+	}
+	if zones := (MouseMsg{}).Zones(); zones != nil {
+		t.Errorf("expected no zones, got %v", zones)
+	}
+}
+
+type zoneViewModel struct{}
+
+func (zoneViewModel) Init() Cmd { return Quit }
+
+func (m zoneViewModel) Update(Msg) (Model, Cmd) { return m, nil }
+
+func (zoneViewModel) View() string { return Zone("btn", "[ok]") }
+
+func TestCustomRendererZones(t *testing.T) {
+	var in bytes.Buffer
+
+	r := &recordingRenderer{}
+	p := NewProgram(zoneViewModel{}, WithInput(&in), WithRenderer(r))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.frames) == 0 {
+		t.Fatal("expected the view to be written to the renderer")
+	}
+	for _, frame := range r.frames {
+		if frame != "[ok]" {
+			t.Errorf("expected the zone markers to be stripped, got %q", frame)
+		}
+	}
+}